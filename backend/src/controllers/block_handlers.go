@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type BlockHandler struct {
+	BlockService *services.BlockService
+}
+
+func (h *BlockHandler) HandleListBlocks(c *gin.Context) {
+	blocks, err := h.BlockService.List(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "block_lookup_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"blocks": blocks})
+}
+
+// HandleUnblock remove o bloqueio; :id é o ID do usuário bloqueado
+func (h *BlockHandler) HandleUnblock(c *gin.Context) {
+	removed, err := h.BlockService.Unblock(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unblock_failed"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "block_not_found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// handleBlockUser bloqueia o parceiro atual (ou user_id) e encerra a sala
+func (h *WSHandler) handleBlockUser(userID string, payload json.RawMessage) {
+	var input struct {
+		UserID string `json:"user_id"`
+	}
+	json.Unmarshal(payload, &input)
+
+	room, partnerID := h.findRoom(userID)
+	target := input.UserID
+	if target == "" {
+		target = partnerID
+	}
+	if target == "" || h.BlockService == nil {
+		h.sendTo(userID, WSMessage{Type: "block_error", Payload: h.mustMarshal(gin.H{"error": "no_target"})})
+		return
+	}
+
+	if err := h.BlockService.Block(userID, target); err != nil {
+		log.Printf("❌ Block %s -> %s failed: %v", userID, target, err)
+		h.sendTo(userID, WSMessage{Type: "block_error", Payload: h.mustMarshal(gin.H{"error": "block_failed"})})
+		return
+	}
+
+	if room != nil && target == partnerID {
+		h.closeRoom(userID, "blocked")
+	}
+	log.Printf("⛔ User %s blocked %s", userID, target)
+	h.sendTo(userID, WSMessage{Type: "user_blocked", Payload: h.mustMarshal(gin.H{"user_id": target})})
+}
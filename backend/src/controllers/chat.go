@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// Valores de translation_status no chat_message / translation_done
+const (
+	translationOK          = "ok"
+	translationPending     = "pending"
+	translationSkipped     = "skipped"
+	translationQuota       = "quota_exceeded"
+	translationDegraded    = "degraded"
+	translationFailed      = "failed"
+	translationUnavailable = "unavailable"
+)
+
+// detectionDeadline vale para detectores remotos; o local responde na hora
+const detectionDeadline = 2 * time.Second
+
+// translationDeadline limita o tempo total (com retries) da tradução de uma mensagem
+const translationDeadline = 12 * time.Second
+
+// roomTranslationSlots limita quantas mensagens de uma mesma sala são traduzidas
+// ao mesmo tempo; até roomTranslationQueue outras esperam a vez fora do loop de
+// leitura, e além disso o original fica como definitivo (degraded)
+const (
+	roomTranslationSlots = 4
+	roomTranslationQueue = 16
+)
+
+type chatRecipient struct {
+	id, language, mode string
+	streaming          bool
+}
+
+// handleChat entrega a mensagem a cada membro no próprio idioma. Todos recebem
+// o original na hora (translation_status pending). Quem conectou com ?stream=1
+// recebe a tradução em translation_delta / translation_done com o mesmo
+// message_id; os demais recebem um chat_message final que substitui o pendente.
+// O idioma detectado vai em todas as mensagens e, quando já é o do destinatário,
+// a tradução é pulada.
+func (h *WSHandler) handleChat(senderID string, payload json.RawMessage) {
+	var input struct {
+		Text string `json:"text"`
+		// Mensagens sensíveis não passam pelo cache de tradução
+		Sensitive bool `json:"sensitive"`
+	}
+	json.Unmarshal(payload, &input)
+
+	room, _ := h.findRoom(senderID)
+	if room == nil || input.Text == "" {
+		return
+	}
+	if room.Group && !h.refreshGroupRoom(room) {
+		return
+	}
+
+	h.mu.RLock()
+	var recipients []chatRecipient
+	for _, id := range room.others(senderID) {
+		language := room.requests[id].NativeLanguage
+		if language == "" {
+			language = "en"
+		}
+		recipients = append(recipients, chatRecipient{id, language, room.displayMode(id), h.streaming[id]})
+	}
+	hint := room.requests[senderID].NativeLanguage
+	practicing := room.requests[senderID].TargetLanguage
+	coaching := room.coach[senderID]
+	var history []services.ContextMessage
+	if room.history != nil {
+		history = room.history.Messages()
+	}
+	h.mu.RUnlock()
+
+	detection, detected := h.detectLanguage(input.Text)
+	source := hint
+	if detected {
+		source = detection.Language
+	}
+	languageChanged := false
+	h.mu.Lock()
+	if detected {
+		if room.spoken == nil {
+			room.spoken = make(map[string]string)
+		}
+		previous := room.spoken[senderID]
+		languageChanged = previous != "" && !services.SameLanguage(previous, detection.Language)
+		room.spoken[senderID] = detection.Language
+	}
+	// Mensagens sensíveis não entram no contexto das próximas traduções
+	if h.ContextTokens > 0 && !input.Sensitive {
+		if room.history == nil {
+			room.history = services.NewConversationWindow(h.ContextTokens)
+		}
+		room.history.Add(peerName(senderID), input.Text)
+	}
+	h.mu.Unlock()
+
+	messageID := uuid.NewString()
+
+	// Modo coach: só revisa o que foi escrito no idioma que o autor está
+	// aprendendo; sem detector, confia que quem ligou o modo está praticando
+	if coaching && h.Coach != nil && practicing != "" {
+		inTarget := h.LanguageDetector == nil
+		if detected {
+			inTarget = services.SameLanguage(source, practicing)
+		}
+		if inTarget {
+			h.startCoach(room, messageID, services.CoachRequest{
+				Text:           input.Text,
+				Language:       practicing,
+				NativeLanguage: hint,
+				UserID:         senderID,
+			})
+		}
+	}
+	timestamp := time.Now().UnixMilli()
+	message := func(r chatRecipient, source string) gin.H {
+		msg := gin.H{
+			"message_id":      messageID,
+			"from":            senderID,
+			"room_id":         room.ID,
+			"source_language": source,
+			"target_language": r.language,
+			"display_mode":    r.mode,
+			"timestamp":       timestamp,
+		}
+		if h.LanguageDetector != nil {
+			msg["detected_language"] = detection.Language
+			msg["detection_confidence"] = detection.Confidence
+			msg["language_changed"] = languageChanged
+		}
+		if r.mode != displayTranslated {
+			msg["text"] = input.Text
+		}
+		return msg
+	}
+
+	// Cada membro recebe no próprio idioma; traduzimos uma vez por idioma
+	byLanguage := make(map[string][]chatRecipient)
+	for _, r := range recipients {
+		switch {
+		case r.mode == displayOriginal:
+			h.sendTo(r.id, WSMessage{Type: "chat_message", Payload: h.mustMarshal(message(r, source))})
+			continue
+		case detected && services.SameLanguage(source, r.language):
+			// Já está no idioma do destinatário: nada a traduzir
+			msg := message(r, source)
+			msg["translated_text"] = input.Text
+			msg["translation_status"] = translationSkipped
+			h.sendTo(r.id, WSMessage{Type: "chat_message", Payload: h.mustMarshal(msg)})
+			continue
+		default:
+			msg := message(r, source)
+			msg["translation_status"] = translationPending
+			if r.streaming {
+				msg["streaming"] = true
+			}
+			h.sendTo(r.id, WSMessage{Type: "chat_message", Payload: h.mustMarshal(msg)})
+		}
+		byLanguage[r.language] = append(byLanguage[r.language], r)
+	}
+
+	if len(byLanguage) == 0 {
+		return
+	}
+
+	original := &services.Translation{Text: input.Text, SourceLanguage: source}
+
+	// A tradução não pode segurar o loop de leitura (sinalização, next, etc.)
+	queue, slots := h.translationSlots(room)
+	select {
+	case queue <- struct{}{}:
+	default:
+		log.Printf("⚠️ Translation queue full in room %s", room.ID)
+		for language, group := range byLanguage {
+			h.finishTranslation(messageID, language, group, message, original, translationDegraded)
+		}
+		return
+	}
+	go func() {
+		defer func() { <-queue }()
+		slots <- struct{}{}
+		defer func() { <-slots }()
+
+		glossary := h.roomGlossary(room, senderID, recipients)
+		var (
+			wg        sync.WaitGroup
+			quotaOnce sync.Once
+		)
+		for language, group := range byLanguage {
+			wg.Add(1)
+			go func(language string, group []chatRecipient) {
+				defer wg.Done()
+				req := services.TranslationRequest{
+					Text:     input.Text,
+					Source:   hint,
+					Target:   language,
+					UserID:   senderID,
+					NoCache:  input.Sensitive,
+					Context:  history,
+					Glossary: glossary,
+				}
+				if detected {
+					req.Detected = source
+				}
+				if quota := h.deliverTranslation(messageID, req, group, message); quota != nil {
+					quotaOnce.Do(func() { h.notifyQuota(senderID, messageID, quota) })
+				}
+			}(language, group)
+		}
+		wg.Wait()
+	}()
+}
+
+// translationSlots devolve a fila (traduções aceitas, em andamento ou esperando)
+// e o semáforo das em andamento da sala, criando-os na primeira mensagem
+func (h *WSHandler) translationSlots(room *Room) (queue, slots chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if room.translating == nil {
+		room.translationQueue = make(chan struct{}, roomTranslationSlots+roomTranslationQueue)
+		room.translating = make(chan struct{}, roomTranslationSlots)
+	}
+	return room.translationQueue, room.translating
+}
+
+// deliverTranslation traduz para um idioma e repassa o resultado ao grupo de
+// destinatários que o lê; devolve o erro de cota, se o autor estourou a dele
+func (h *WSHandler) deliverTranslation(messageID string, req services.TranslationRequest, group []chatRecipient, message func(chatRecipient, string) gin.H) *services.QuotaError {
+	var onDelta func(string)
+	for _, r := range group {
+		if r.streaming {
+			onDelta = func(delta string) {
+				for _, r := range group {
+					if r.streaming {
+						h.sendTo(r.id, WSMessage{Type: "translation_delta", Payload: h.mustMarshal(gin.H{
+							"message_id":      messageID,
+							"target_language": req.Target,
+							"delta":           delta,
+						})})
+					}
+				}
+			}
+			break
+		}
+	}
+
+	t, status, quota := h.translate(req, onDelta)
+	if t.SourceLanguage == "" {
+		withSource := *t
+		withSource.SourceLanguage = req.Source
+		t = &withSource
+	}
+	h.finishTranslation(messageID, req.Target, group, message, t, status)
+	return quota
+}
+
+// finishTranslation entrega o resultado final: translation_done para quem
+// acompanha o streaming, chat_message definitivo para os demais
+func (h *WSHandler) finishTranslation(messageID, target string, group []chatRecipient, message func(chatRecipient, string) gin.H, t *services.Translation, status string) {
+	source := t.SourceLanguage
+	for _, r := range group {
+		if r.streaming {
+			h.sendTo(r.id, WSMessage{Type: "translation_done", Payload: h.mustMarshal(gin.H{
+				"message_id":         messageID,
+				"source_language":    source,
+				"target_language":    target,
+				"translated_text":    t.Text,
+				"translation_status": status,
+			})})
+			continue
+		}
+		msg := message(r, source)
+		msg["translated_text"] = t.Text
+		msg["translation_status"] = status
+		h.sendTo(r.id, WSMessage{Type: "chat_message", Payload: h.mustMarshal(msg)})
+	}
+}
+
+// detectLanguage retorna a detecção e se ela é confiável o bastante para
+// substituir o idioma declarado; sem detector ou com erro, fica a dica
+func (h *WSHandler) detectLanguage(text string) (services.Detection, bool) {
+	if h.LanguageDetector == nil {
+		return services.Detection{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), detectionDeadline)
+	defer cancel()
+	detection, err := h.LanguageDetector.Detect(ctx, text)
+	if err != nil {
+		log.Printf("⚠️ Language detection failed: %v", err)
+		return services.Detection{}, false
+	}
+	return detection, detection.Confidence >= services.MinDetectionConfidence
+}
+
+// translate nunca falha: sem provedor, com o breaker aberto, sem cota ou com
+// erro, o texto original é entregue e o status indica o motivo. Com onDelta a
+// tradução é pedida em streaming.
+func (h *WSHandler) translate(req services.TranslationRequest, onDelta func(string)) (*services.Translation, string, *services.QuotaError) {
+	original := &services.Translation{Text: req.Text, SourceLanguage: req.Source}
+	if req.Detected != "" {
+		original.SourceLanguage = req.Detected
+	}
+	if h.Translator == nil {
+		return original, translationUnavailable, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), translationDeadline)
+	defer cancel()
+	var t *services.Translation
+	var err error
+	if onDelta != nil {
+		t, err = services.TranslateStream(ctx, h.Translator, req, onDelta)
+	} else {
+		t, err = h.Translator.Translate(ctx, req)
+	}
+	var quota *services.QuotaError
+	switch {
+	case errors.As(err, &quota):
+		return original, translationQuota, quota
+	case errors.Is(err, services.ErrCircuitOpen):
+		return original, translationDegraded, nil
+	case err != nil:
+		log.Printf("⚠️ Translation to %s failed: %v", req.Target, err)
+		return original, translationFailed, nil
+	}
+	return t, translationOK, nil
+}
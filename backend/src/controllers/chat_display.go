@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// Modos de exibição do chat por membro da sala
+const (
+	displayOriginal   = "original"
+	displayTranslated = "translated"
+	displayBoth       = "both"
+)
+
+// handleDisplayMode define o que o usuário quer ver nas mensagens recebidas nesta sala
+func (h *WSHandler) handleDisplayMode(userID string, payload json.RawMessage) {
+	var input struct {
+		Mode string `json:"mode"`
+	}
+	json.Unmarshal(payload, &input)
+
+	if input.Mode != displayOriginal && input.Mode != displayTranslated && input.Mode != displayBoth {
+		h.sendTo(userID, WSMessage{Type: "display_mode_error", Payload: h.mustMarshal(gin.H{"error": "invalid_mode"})})
+		return
+	}
+	room, _ := h.findRoom(userID)
+	if room == nil {
+		h.sendTo(userID, WSMessage{Type: "display_mode_error", Payload: h.mustMarshal(gin.H{"error": "not_in_room"})})
+		return
+	}
+
+	h.mu.Lock()
+	if room.display == nil {
+		room.display = make(map[string]string)
+	}
+	room.display[userID] = input.Mode
+	h.mu.Unlock()
+
+	h.sendTo(userID, WSMessage{Type: "display_mode", Payload: h.mustMarshal(gin.H{
+		"room_id": room.ID,
+		"mode":    input.Mode,
+	})})
+}
+
+// displayMode retorna o modo do membro; chamar com h.mu travado
+func (r *Room) displayMode(userID string) string {
+	if mode, ok := r.display[userID]; ok {
+		return mode
+	}
+	return displayBoth
+}
+
+// withProfileLanguages completa os idiomas ausentes do pedido com os de models.User
+func (h *WSHandler) withProfileLanguages(req services.MatchRequest) services.MatchRequest {
+	if h.DB == nil || req.Bot || (req.NativeLanguage != "" && req.TargetLanguage != "") {
+		return req
+	}
+	var user models.User
+	if err := h.DB.Select("native_language", "target_language").First(&user, "id = ?", req.UserID).Error; err != nil {
+		return req
+	}
+	if req.NativeLanguage == "" {
+		req.NativeLanguage = user.NativeLanguage
+	}
+	if req.TargetLanguage == "" {
+		req.TargetLanguage = user.TargetLanguage
+	}
+	return req
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// coachDeadline: a correção chega depois da mensagem, então pode esperar mais que a tradução
+const coachDeadline = 20 * time.Second
+
+// roomCoachSlots limita as revisões em andamento por sala; acima disso a
+// mensagem fica sem correção (correction_failed com coach_busy)
+const roomCoachSlots = 2
+
+// handleCoachMode liga ou desliga o modo coach do usuário nesta sala
+func (h *WSHandler) handleCoachMode(userID string, payload json.RawMessage) {
+	var input struct {
+		Enabled bool `json:"enabled"`
+	}
+	json.Unmarshal(payload, &input)
+
+	if h.Coach == nil {
+		h.sendTo(userID, WSMessage{Type: "coach_mode_error", Payload: h.mustMarshal(gin.H{"error": "coach_unavailable"})})
+		return
+	}
+	room, _ := h.findRoom(userID)
+	if room == nil {
+		h.sendTo(userID, WSMessage{Type: "coach_mode_error", Payload: h.mustMarshal(gin.H{"error": "not_in_room"})})
+		return
+	}
+
+	h.mu.Lock()
+	if room.coach == nil {
+		room.coach = make(map[string]bool)
+	}
+	room.coach[userID] = input.Enabled
+	h.mu.Unlock()
+
+	h.sendTo(userID, WSMessage{Type: "coach_mode", Payload: h.mustMarshal(gin.H{
+		"room_id": room.ID,
+		"enabled": input.Enabled,
+	})})
+}
+
+// startCoach revisa a mensagem fora do loop de leitura, sem passar de roomCoachSlots por sala
+func (h *WSHandler) startCoach(room *Room, messageID string, req services.CoachRequest) {
+	h.mu.Lock()
+	if room.coaching == nil {
+		room.coaching = make(chan struct{}, roomCoachSlots)
+	}
+	slots := room.coaching
+	h.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		h.correctionFailed(req.UserID, room.ID, messageID, "coach_busy")
+		return
+	}
+	go func() {
+		defer func() { <-slots }()
+		h.coachMessage(room.ID, messageID, req)
+	}()
+}
+
+// coachMessage envia a correção só para o autor; o parceiro nunca a recebe
+func (h *WSHandler) coachMessage(roomID, messageID string, req services.CoachRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), coachDeadline)
+	defer cancel()
+
+	// O coach também chama o provedor, então conta na mesma cota da tradução
+	if h.UsageService != nil {
+		var quota *services.QuotaError
+		if err := h.UsageService.Check(ctx, req.UserID); errors.As(err, &quota) {
+			h.notifyQuota(req.UserID, messageID, quota)
+			return
+		}
+	}
+
+	correction, err := h.Coach.Correct(ctx, req)
+	if err != nil {
+		// Com o breaker aberto o provedor está fora; não adianta o cliente insistir
+		code := "correction_failed"
+		if errors.Is(err, services.ErrCircuitOpen) {
+			code = "coach_unavailable"
+		} else {
+			log.Printf("⚠️ Coach failed for %s: %v", req.UserID, err)
+		}
+		h.correctionFailed(req.UserID, roomID, messageID, code)
+		return
+	}
+	if h.UsageService != nil {
+		tokens := correction.Tokens
+		if tokens == 0 {
+			tokens = services.EstimateTokens(req.Text) + services.EstimateTokens(correction.Corrected)
+		}
+		if err := h.UsageService.Record(ctx, req.UserID, int64(utf8.RuneCountInString(req.Text)), int64(tokens)); err != nil {
+			log.Printf("⚠️ Usage not recorded for %s: %v", req.UserID, err)
+		}
+	}
+
+	h.sendTo(req.UserID, WSMessage{Type: "correction", Payload: h.mustMarshal(gin.H{
+		"message_id": messageID,
+		"room_id":    roomID,
+		"language":   req.Language,
+		"original":   req.Text,
+		"corrected":  correction.Corrected,
+		"edits":      correction.Edits,
+		"level":      correction.Level,
+	})})
+}
+
+func (h *WSHandler) correctionFailed(userID, roomID, messageID, code string) {
+	h.sendTo(userID, WSMessage{Type: "correction_failed", Payload: h.mustMarshal(gin.H{
+		"message_id": messageID,
+		"room_id":    roomID,
+		"error":      code,
+	})})
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type GlossaryHandler struct {
+	GlossaryService *services.GlossaryService
+	// WS descarta o glossário em cache nas salas quando o usuário o edita
+	WS *WSHandler
+}
+
+func (h *GlossaryHandler) HandleListGlossary(c *gin.Context) {
+	entries, err := h.GlossaryService.List(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "glossary_lookup_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (h *GlossaryHandler) HandleCreateGlossaryEntry(c *gin.Context) {
+	var input services.GlossaryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	entry, err := h.GlossaryService.Create(c.GetString("user_id"), input)
+	if err != nil {
+		c.JSON(glossaryErrorStatus(err), gin.H{"error": glossaryErrorCode(err)})
+		return
+	}
+	h.forget(c.GetString("user_id"))
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *GlossaryHandler) HandleUpdateGlossaryEntry(c *gin.Context) {
+	var input services.GlossaryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	entry, err := h.GlossaryService.Update(c.GetString("user_id"), c.Param("id"), input)
+	if err != nil {
+		c.JSON(glossaryErrorStatus(err), gin.H{"error": glossaryErrorCode(err)})
+		return
+	}
+	h.forget(c.GetString("user_id"))
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *GlossaryHandler) HandleDeleteGlossaryEntry(c *gin.Context) {
+	removed, err := h.GlossaryService.Delete(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "glossary_delete_failed"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrGlossaryEntryNotFound.Error()})
+		return
+	}
+	h.forget(c.GetString("user_id"))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *GlossaryHandler) forget(userID string) {
+	if h.WS != nil {
+		h.WS.forgetGlossary(userID)
+	}
+}
+
+// roomGlossary junta os glossários do autor (que tem prioridade) e dos
+// destinatários. Os termos de cada membro ficam na sala: só quem ainda não
+// está lá (sala nova, membro novo, glossário editado) é lido do banco. Sem o
+// serviço ou com erro, a mensagem segue com o que já estava carregado.
+func (h *WSHandler) roomGlossary(room *Room, senderID string, recipients []chatRecipient) []services.GlossaryTerm {
+	if h.GlossaryService == nil {
+		return nil
+	}
+	ids := []string{senderID}
+	for _, r := range recipients {
+		ids = append(ids, r.id)
+	}
+
+	h.mu.RLock()
+	var missing []string
+	for _, id := range ids {
+		if _, ok := room.glossaries[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(missing) > 0 {
+		loaded, err := h.GlossaryService.UserGlossaries(missing...)
+		if err != nil {
+			log.Printf("⚠️ Glossary lookup failed: %v", err)
+		} else {
+			h.mu.Lock()
+			if room.glossaries == nil {
+				room.glossaries = make(map[string][]services.GlossaryTerm)
+			}
+			for _, id := range missing {
+				room.glossaries[id] = loaded[id]
+			}
+			h.mu.Unlock()
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return services.MergeGlossaries(room.glossaries, ids...)
+}
+
+// forgetGlossary descarta os termos do usuário guardados nas salas desta
+// instância, para a próxima mensagem reler o glossário editado
+func (h *WSHandler) forgetGlossary(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, room := range h.rooms {
+		delete(room.glossaries, userID)
+	}
+}
+
+func glossaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrGlossaryTermRequired),
+		errors.Is(err, services.ErrGlossaryTermTooLong),
+		errors.Is(err, services.ErrGlossaryPairRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrGlossaryEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGlossaryEntryExists), errors.Is(err, services.ErrGlossaryFull):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func glossaryErrorCode(err error) string {
+	if glossaryErrorStatus(err) == http.StatusInternalServerError {
+		log.Printf("❌ Glossary update failed: %v", err)
+		return "glossary_update_failed"
+	}
+	return err.Error()
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// handleJoinGroup coloca o usuário numa mesa de conversa em grupo (3–6 pessoas)
+// do idioma que ele pratica e do seu nível
+func (h *WSHandler) handleJoinGroup(userID string, payload json.RawMessage) {
+	if h.GroupService == nil {
+		h.sendTo(userID, WSMessage{Type: "group_error", Payload: h.mustMarshal(gin.H{"error": "groups_unavailable"})})
+		return
+	}
+
+	var req services.GroupRequest
+	json.Unmarshal(payload, &req)
+	req.UserID = userID
+
+	if room, _ := h.findRoom(userID); room != nil && !room.Group {
+		h.sendTo(userID, WSMessage{Type: "group_error", Payload: h.mustMarshal(gin.H{"error": "already_in_room"})})
+		return
+	}
+	if req.NativeLanguage == "" {
+		req.NativeLanguage = h.withProfileLanguages(services.MatchRequest{UserID: userID}).NativeLanguage
+	}
+	// Quem entra numa mesa sai da fila de pares
+	h.MatchService.RemoveFromQueue(userID)
+
+	group, err := h.GroupService.Join(context.Background(), req)
+	if err != nil {
+		code := "group_unavailable"
+		if errors.Is(err, services.ErrInvalidGroupLevel) || errors.Is(err, services.ErrGroupLanguage) {
+			code = err.Error()
+		} else {
+			log.Printf("❌ Group join failed for %s: %v", userID, err)
+		}
+		h.sendTo(userID, WSMessage{Type: "group_error", Payload: h.mustMarshal(gin.H{"error": code})})
+		return
+	}
+
+	room, rejoined := h.syncGroupRoom(group, userID)
+	if err := h.MatchService.AddRoomMember(room.ID, userID); err != nil {
+		log.Printf("❌ Failed to register %s in group %s: %v", userID, room.ID, err)
+	}
+	createLiveKitRoom(h.LiveKitService, room.ID, group.Capacity)
+
+	h.sendTo(userID, WSMessage{Type: "group_joined", Payload: h.mustMarshal(gin.H{
+		"room_id":  group.ID,
+		"capacity": group.Capacity,
+		"members":  group.Members,
+		"active":   group.Active(),
+	})})
+	if rejoined {
+		return
+	}
+
+	log.Printf("👥 User %s joined group %s (%d/%d)", userID, group.ID, len(group.Members), group.Capacity)
+	h.broadcastGroup(group, userID, WSMessage{Type: "group_member_joined", Payload: h.mustMarshal(gin.H{
+		"room_id":       group.ID,
+		"member":        req,
+		"members_count": len(group.Members),
+		"active":        group.Active(),
+	})})
+}
+
+// leaveGroup tira o usuário da mesa; a mesa é encerrada quando sobra um só membro
+func (h *WSHandler) leaveGroup(room *Room, userID, reason string) {
+	group, err := h.GroupService.Leave(context.Background(), userID)
+	if err != nil {
+		log.Printf("❌ Group leave failed for %s: %v", userID, err)
+	}
+
+	h.mu.Lock()
+	switch {
+	case group != nil && group.Closed:
+		delete(h.rooms, room.ID)
+		room.history = nil
+	case group != nil:
+		room.Members = memberIDs(group)
+		delete(room.requests, userID)
+	default:
+		// Sem estado no Redis: ajustamos só a cópia local
+		room.Members = room.others(userID)
+		delete(room.requests, userID)
+		if len(room.Members) == 0 {
+			delete(h.rooms, room.ID)
+			room.history = nil
+		}
+	}
+	h.mu.Unlock()
+
+	h.MatchService.RemoveRoomMember(room.ID, userID)
+	if reason == "left" {
+		h.sendTo(userID, WSMessage{Type: "group_left", Payload: h.mustMarshal(gin.H{"room_id": room.ID})})
+	}
+	if group == nil {
+		return
+	}
+
+	if group.Closed {
+		h.MatchService.ReleaseRoom(room.ID, group.Evicted...)
+		for _, id := range group.Evicted {
+			h.sendTo(id, WSMessage{Type: "group_closed", Payload: h.mustMarshal(gin.H{
+				"room_id": room.ID,
+				"reason":  "not_enough_members",
+			})})
+		}
+		log.Printf("🚪 Group %s closed", room.ID)
+		return
+	}
+
+	h.broadcastGroup(group, userID, WSMessage{Type: "group_member_left", Payload: h.mustMarshal(gin.H{
+		"room_id":       room.ID,
+		"user_id":       userID,
+		"reason":        reason,
+		"members_count": len(group.Members),
+		"active":        group.Active(),
+	})})
+}
+
+// syncGroupRoom atualiza a cópia local da mesa; rejoined indica que o usuário já estava nela
+func (h *WSHandler) syncGroupRoom(group *services.GroupRoom, userID string) (*Room, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[group.ID]
+	if !ok {
+		room = &Room{ID: group.ID, Group: true, startedAt: time.Now()}
+		h.rooms[group.ID] = room
+	}
+	rejoined := room.has(userID)
+
+	room.Members = memberIDs(group)
+	room.requests = make(map[string]services.MatchRequest, len(group.Members))
+	for _, m := range group.Members {
+		room.requests[m.UserID] = services.MatchRequest{
+			UserID:         m.UserID,
+			NativeLanguage: m.NativeLanguage,
+			TargetLanguage: m.TargetLanguage,
+		}
+	}
+	return room, rejoined
+}
+
+// refreshGroupRoom relê os membros da mesa no Redis, já que entradas e saídas
+// por outras instâncias não passam pela cópia local. Retorna false se a mesa acabou.
+func (h *WSHandler) refreshGroupRoom(room *Room) bool {
+	if h.GroupService == nil {
+		return true
+	}
+	group, err := h.GroupService.Room(context.Background(), room.ID)
+	if err != nil {
+		// Sem Redis, seguimos com a cópia local
+		return true
+	}
+	if len(group.Members) == 0 {
+		h.mu.Lock()
+		delete(h.rooms, room.ID)
+		room.history = nil
+		h.mu.Unlock()
+		return false
+	}
+	h.syncGroupRoom(group, "")
+	return true
+}
+
+// broadcastGroup avisa os membros da mesa, em qualquer instância
+func (h *WSHandler) broadcastGroup(group *services.GroupRoom, exceptID string, msg WSMessage) {
+	for _, m := range group.Members {
+		if m.UserID != exceptID {
+			h.sendTo(m.UserID, msg)
+		}
+	}
+}
+
+func memberIDs(group *services.GroupRoom) []string {
+	ids := make([]string, len(group.Members))
+	for i, m := range group.Members {
+		ids[i] = m.UserID
+	}
+	return ids
+}
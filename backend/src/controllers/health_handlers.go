@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type HealthHandler struct {
+	Translator       services.Translator
+	TranslationCache *services.CachedTranslator
+	Coach            services.Coach
+}
+
+// HandleHealth mostra o provedor de tradução, o estado dos circuit breakers
+// (tradução e coach) e as estatísticas do cache
+func (h *HealthHandler) HandleHealth(c *gin.Context) {
+	provider := "none"
+	if h.Translator != nil {
+		provider = h.Translator.Name()
+	}
+	health := gin.H{"status": "ok", "neural_bridge": h.Translator != nil, "translation_provider": provider}
+	if h.Translator != nil {
+		health["translation_breakers"] = services.TranslatorBreakers(h.Translator)
+	}
+	if coach, ok := h.Coach.(services.Translator); ok {
+		health["coach_breakers"] = services.TranslatorBreakers(coach)
+	}
+	if h.TranslationCache != nil {
+		if stats, err := h.TranslationCache.Stats(c.Request.Context()); err == nil {
+			health["translation_cache"] = stats
+		}
+	}
+	c.JSON(http.StatusOK, health)
+}
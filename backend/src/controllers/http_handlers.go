@@ -9,8 +9,11 @@ import (
 )
 
 type NexusHandler struct {
-	AuthService  *services.AuthService
-	MatchService *services.MatchService
+	AuthService    *services.AuthService
+	MatchService   *services.MatchService
+	LiveKitService *services.LiveKitService
+	// Pareamentos via REST abrem a sala pelo mesmo caminho do WebSocket
+	WS *WSHandler
 }
 
 func (h *NexusHandler) HandleAnonymousAuth(c *gin.Context) {
@@ -37,7 +40,11 @@ func (h *NexusHandler) HandleAnonymousAuth(c *gin.Context) {
 
 func (h *NexusHandler) HandleJoinQueue(c *gin.Context) {
 	userID := c.GetString("user_id")
-	var prefs models.User
+	var prefs struct {
+		models.User
+		Interests []string `json:"interests"`
+		Country   string   `json:"country"`
+	}
 	// Atualiza preferências antes de entrar na fila
 	if err := c.ShouldBindJSON(&prefs); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_prefs"})
@@ -48,19 +55,50 @@ func (h *NexusHandler) HandleJoinQueue(c *gin.Context) {
 		UserID:         userID,
 		NativeLanguage: prefs.NativeLanguage,
 		TargetLanguage: prefs.TargetLanguage,
+		Interests:      prefs.Interests,
+		Country:        prefs.Country,
+	}
+
+	// Match imediato ou entrada na fila, numa única operação atômica
+	result, err := h.MatchService.MatchOrEnqueue(matchReq)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "matchmaking_unavailable"})
+		return
 	}
+	if result.Status == services.MatchStatusMatched {
+		partner := result.Partner
+		roomID := result.RoomID
+		h.WS.openRoom(matchReq, *partner, roomID)
 
-	// Tenta match imediato
-	partner, _ := h.MatchService.FindMatch(matchReq)
-	if partner != nil {
 		c.JSON(http.StatusOK, gin.H{
-			"status":     "connected",
-			"partner_id": partner.UserID,
-			"room_id":    "room_" + userID + "_" + partner.UserID,
+			"status":           "connected",
+			"partner_id":       partner.UserID,
+			"room_id":          roomID,
+			"common_interests": result.CommonInterests,
 		})
 		return
 	}
 
-	h.MatchService.AddToQueue(matchReq)
 	c.JSON(http.StatusAccepted, gin.H{"status": "searching"})
 }
+
+// HandleMatchStatus é o fallback via polling para clientes sem WebSocket
+func (h *NexusHandler) HandleMatchStatus(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	state, err := h.MatchService.Status(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "matchmaking_unavailable"})
+		return
+	}
+	if state == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_in_queue"})
+		return
+	}
+
+	if state.Status == services.MatchStateMatched && h.LiveKitService != nil {
+		state.LiveKitToken, _ = h.LiveKitService.ParticipantToken(userID, c.GetString("anonymous_id"), state.RoomID)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+const queueStatusInterval = 5 * time.Second
+
+// RunMatchmaker reexamina periodicamente quem está esperando. Com várias
+// instâncias, apenas a líder faz o sweep; os pareamentos são publicados no Redis
+// e a instância que reivindica a sala a abre (ver RunRelay).
+func (h *WSHandler) RunMatchmaker(ctx context.Context, interval time.Duration) {
+	events := h.MatchService.SubscribeEvents(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	statusTicker := time.NewTicker(queueStatusInterval)
+	defer statusTicker.Stop()
+
+	leader := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			h.dispatchMatchEvent(event)
+
+		case <-statusTicker.C:
+			h.pushQueueStatus(ctx)
+
+		case <-ticker.C:
+			isLeader, err := h.MatchService.AcquireLeadership(ctx, h.InstanceID, 3*interval)
+			if err != nil {
+				log.Printf("❌ Matchmaker leader election failed: %v", err)
+				continue
+			}
+			if isLeader != leader {
+				leader = isLeader
+				log.Printf("👑 Matchmaker %s leader=%v", h.InstanceID[:8], leader)
+			}
+			if !leader {
+				continue
+			}
+
+			swept, err := h.MatchService.Sweep(ctx)
+			if err != nil {
+				log.Printf("❌ Matchmaker sweep failed: %v", err)
+			}
+			for _, event := range swept {
+				if event.Pair != nil {
+					log.Printf("🎯 Sweep matched %s ↔ %s", event.Pair.User.UserID, event.Pair.Partner.UserID)
+				}
+				if err := h.MatchService.PublishEvent(ctx, event); err != nil {
+					log.Printf("❌ Failed to publish match event: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// dispatchMatchEvent entrega o evento aos usuários conectados nesta instância.
+// Todas recebem o evento; openRoom garante que só uma delas abre a sala.
+func (h *WSHandler) dispatchMatchEvent(event services.MatchEvent) {
+	switch {
+	case event.Pair != nil:
+		pair := event.Pair
+		if h.isConnected(pair.User.UserID) || h.isConnected(pair.Partner.UserID) {
+			h.openRoom(pair.User, pair.Partner, pair.RoomID)
+		}
+	case event.Update != nil:
+		h.sendLocal(event.UserID, WSMessage{Type: "queue_update", Payload: h.mustMarshal(event.Update)})
+	}
+}
+
+// pushQueueStatus envia posição e ETA aos usuários desta instância que estão na fila
+func (h *WSHandler) pushQueueStatus(ctx context.Context) {
+	h.mu.RLock()
+	userIDs := make([]string, 0, len(h.connections))
+	for id := range h.connections {
+		userIDs = append(userIDs, id)
+	}
+	h.mu.RUnlock()
+
+	for _, id := range userIDs {
+		state, err := h.MatchService.Status(ctx, id)
+		if err != nil || state == nil || state.Status != services.MatchStateSearching || state.Position == 0 {
+			continue
+		}
+		h.sendTo(id, WSMessage{Type: "queue_update", Payload: h.mustMarshal(services.QueueUpdate{
+			Reason:     "position",
+			Position:   state.Position,
+			ETASeconds: state.ETASeconds,
+		})})
+	}
+}
+
+func (h *WSHandler) isConnected(userID string) bool {
+	if bot := services.BotSessionOwner(userID); bot != "" {
+		userID = bot
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.connections[userID]
+	return ok
+}
+
+// isOnline diz se o usuário está conectado aqui ou em outra instância
+func (h *WSHandler) isOnline(userID string) bool {
+	return h.isConnected(userID) || h.MatchService.IsOnline(userID)
+}
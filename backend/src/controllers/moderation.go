@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// Conversas mais curtas que isso não contam como concluídas; sair abruptamente
+// antes disso conta como early_disconnect
+const minCompletedSession = 2 * time.Minute
+
+// Só dá para denunciar quem está na sala ou conversou com o usuário nesse período
+const reportWindow = 7 * 24 * time.Hour
+
+type ModerationHandler struct {
+	ReputationService *services.ReputationService
+}
+
+// HandleUpholdReport confirma uma denúncia (rota administrativa)
+func (h *ModerationHandler) HandleUpholdReport(c *gin.Context) {
+	report, err := h.ReputationService.UpholdReport(c.Param("id"))
+	if errors.Is(err, services.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "uphold_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *WSHandler) handleReportUser(userID string, payload json.RawMessage) {
+	var input struct {
+		UserID  string `json:"user_id"`
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	json.Unmarshal(payload, &input)
+
+	target := input.UserID
+	if target == "" {
+		_, target = h.findRoom(userID)
+	}
+	if target == "" || h.ReputationService == nil {
+		h.sendTo(userID, WSMessage{Type: "report_error", Payload: h.mustMarshal(gin.H{"error": "no_target"})})
+		return
+	}
+	if !h.metPartner(userID, target) {
+		h.sendTo(userID, WSMessage{Type: "report_error", Payload: h.mustMarshal(gin.H{"error": "not_a_partner"})})
+		return
+	}
+
+	report, err := h.ReputationService.Report(userID, target, input.Reason, input.Details)
+	if err != nil && report == nil {
+		log.Printf("❌ Report %s -> %s failed: %v", userID, target, err)
+		h.sendTo(userID, WSMessage{Type: "report_error", Payload: h.mustMarshal(gin.H{"error": "report_failed"})})
+		return
+	}
+
+	log.Printf("🚨 Report submitted: %s reported %s for %s", userID, target, input.Reason)
+	h.sendTo(userID, WSMessage{Type: "report_submitted", Payload: h.mustMarshal(gin.H{"report_id": report.ID})})
+}
+
+// metPartner diz se target divide a sala com userID (em qualquer instância), se
+// a dupla está no cooldown de reencontro ou se tem uma sessão nos últimos reportWindow
+func (h *WSHandler) metPartner(userID, target string) bool {
+	if userID == target {
+		return false
+	}
+	if room, err := h.MatchService.CurrentRoom(userID); err == nil && room != "" {
+		if other, err := h.MatchService.CurrentRoom(target); err == nil && other == room {
+			return true
+		}
+	}
+	if recent, err := h.MatchService.RecentlyPaired(userID, target); err == nil && recent {
+		return true
+	}
+	if h.SessionService == nil {
+		return false
+	}
+	shared, err := h.SessionService.Shared(userID, target, time.Now().Add(-reportWindow))
+	if err != nil {
+		log.Printf("⚠️ Session lookup failed for report %s -> %s: %v", userID, target, err)
+	}
+	return shared
+}
+
+// settleReputation lança no ledger o resultado da conversa que acabou de fechar
+func (h *WSHandler) settleReputation(room *Room, closedBy, reason string) {
+	if h.ReputationService == nil || room.startedAt.IsZero() {
+		return
+	}
+
+	// Uma entrada por sessão; sem sessão gravada, a sala (que é nova a cada
+	// pareamento) faz o mesmo papel. O cooldown de reencontro limita quantas
+	// sessões a mesma dupla soma.
+	ref := room.SessionID
+	if ref == "" {
+		ref = room.ID
+	}
+	if time.Since(room.startedAt) < minCompletedSession {
+		if reason == "disconnected" && !room.requests[closedBy].Bot {
+			if _, err := h.ReputationService.Record(closedBy, services.ReputationEarlyDisconnect, ref); err != nil {
+				log.Printf("⚠️ Reputation update failed for %s: %v", closedBy, err)
+			}
+		}
+		return
+	}
+
+	for id, req := range room.requests {
+		if req.Bot {
+			continue
+		}
+		if _, err := h.ReputationService.Record(id, services.ReputationSessionCompleted, ref); err != nil {
+			log.Printf("⚠️ Reputation update failed for %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type RatingHandler struct {
+	RatingService *services.RatingService
+}
+
+func (h *RatingHandler) HandleRateSession(c *gin.Context) {
+	var input services.RatingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	// O ID vai direto para uma coluna uuid; lixo aqui viraria erro do Postgres (500)
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_session_id"})
+		return
+	}
+
+	rating, err := h.RatingService.Rate(c.Param("id"), c.GetString("user_id"), input)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{"error": ratingErrorCode(err)})
+		return
+	}
+	c.JSON(http.StatusCreated, rating)
+}
+
+func (h *RatingHandler) HandleUserRatings(c *gin.Context) {
+	stats, err := h.RatingService.Stats(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "rating_lookup_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// handleRatePartner aceita session_id (enviado em matched/partner_left) ou
+// room_id; sem nenhum dos dois usa a sala atual. Mesas de grupo não têm
+// models.Session (a sessão é de uma dupla), então não são avaliadas.
+func (h *WSHandler) handleRatePartner(userID string, payload json.RawMessage) {
+	var input struct {
+		services.RatingInput
+		SessionID string `json:"session_id"`
+		RoomID    string `json:"room_id"`
+	}
+	json.Unmarshal(payload, &input)
+
+	if h.RatingService == nil {
+		h.sendTo(userID, WSMessage{Type: "rating_error", Payload: h.mustMarshal(gin.H{"error": "ratings_unavailable"})})
+		return
+	}
+	if input.SessionID == "" && input.RoomID == "" {
+		if room, _ := h.findRoom(userID); room != nil {
+			if room.Group {
+				h.sendTo(userID, WSMessage{Type: "rating_error", Payload: h.mustMarshal(gin.H{"error": "group_not_rated"})})
+				return
+			}
+			input.SessionID, input.RoomID = room.SessionID, room.ID
+		}
+	}
+	if input.SessionID != "" {
+		if _, err := uuid.Parse(input.SessionID); err != nil {
+			h.sendTo(userID, WSMessage{Type: "rating_error", Payload: h.mustMarshal(gin.H{"error": "invalid_session_id"})})
+			return
+		}
+	}
+
+	var (
+		rating *models.Rating
+		err    error
+	)
+	if input.SessionID != "" {
+		rating, err = h.RatingService.Rate(input.SessionID, userID, input.RatingInput)
+	} else {
+		rating, err = h.RatingService.RateRoom(input.RoomID, userID, input.RatingInput)
+	}
+	if err != nil {
+		if ratingErrorStatus(err) == http.StatusInternalServerError {
+			log.Printf("❌ Rating by %s failed: %v", userID, err)
+		}
+		h.sendTo(userID, WSMessage{Type: "rating_error", Payload: h.mustMarshal(gin.H{"error": ratingErrorCode(err)})})
+		return
+	}
+
+	h.sendTo(userID, WSMessage{Type: "rating_submitted", Payload: h.mustMarshal(gin.H{
+		"rating_id":  rating.ID,
+		"session_id": rating.SessionID,
+	})})
+}
+
+func ratingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidRating):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotParticipant):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAlreadyRated):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func ratingErrorCode(err error) string {
+	if ratingErrorStatus(err) == http.StatusInternalServerError {
+		return "rating_failed"
+	}
+	return err.Error()
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"log"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// Mensagens que dependem do estado da sala e por isso são tratadas pela instância dona
+var roomMessages = map[string]bool{
+	"leave_group":      true,
+	"set_display_mode": true,
+	"set_coach_mode":   true,
+	"chat_message":     true,
+	"typing":           true,
+	"stop_typing":      true,
+	"webrtc_offer":     true,
+	"webrtc_answer":    true,
+	"webrtc_ice":       true,
+	"ice_failure":      true,
+	"next":             true,
+	"report_user":      true,
+	"rate_partner":     true,
+	"block_user":       true,
+}
+
+// RunRelay recebe o tráfego das outras instâncias: entregas para usuários
+// conectados aqui e mensagens para as salas de que esta instância é dona.
+// As mensagens são tratadas em ordem, como no loop de leitura do socket.
+func (h *WSHandler) RunRelay(ctx context.Context) {
+	for msg := range h.MatchService.SubscribeRelay(ctx) {
+		switch {
+		case msg.To != "":
+			h.sendLocal(msg.To, WSMessage{Type: msg.Type, Payload: msg.Payload})
+		case msg.Instance != h.InstanceID:
+			// Sala de outra instância
+		case msg.Disconnect:
+			h.closeRoom(msg.From, "disconnected")
+		default:
+			h.handleMessage(msg.From, WSMessage{Type: msg.Type, Payload: msg.Payload})
+		}
+	}
+}
+
+// relayToOwner encaminha a mensagem do usuário para a instância dona da sala
+// dele; retorna false quando a sala é desta instância (ou não há sala)
+func (h *WSHandler) relayToOwner(userID string, msg services.RelayMessage) bool {
+	if room, _ := h.findRoom(userID); room != nil {
+		return false
+	}
+	roomID, err := h.MatchService.CurrentRoom(userID)
+	if err != nil || roomID == "" {
+		return false
+	}
+	owner, err := h.MatchService.RoomOwner(roomID)
+	if err != nil || owner == "" || owner == h.InstanceID {
+		return false
+	}
+
+	msg.Instance, msg.From = owner, userID
+	if err := h.MatchService.PublishRelay(context.Background(), msg); err != nil {
+		log.Printf("❌ Failed to relay %s from %s: %v", msg.Type, userID, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type RTCHandler struct {
+	TURNService    *services.TURNService
+	LiveKitService *services.LiveKitService
+	MatchService   *services.MatchService
+	SessionService *services.SessionService
+	WS             *WSHandler
+}
+
+func (h *RTCHandler) HandleICEServers(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	resp, err := h.TURNService.ICEServers(userID, c.Query("region"))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *RTCHandler) HandleToken(c *gin.Context) {
+	if h.LiveKitService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "livekit_not_configured"})
+		return
+	}
+	userID := c.GetString("user_id")
+
+	roomID, err := h.MatchService.CurrentRoom(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "room_lookup_failed"})
+		return
+	}
+	if roomID == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_in_room"})
+		return
+	}
+	if requested := c.Query("room"); requested != "" && requested != roomID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "room_forbidden"})
+		return
+	}
+
+	token, err := h.LiveKitService.ParticipantToken(userID, c.GetString("anonymous_id"), roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_generation_failed"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"room_name": roomID,
+		"url":       h.LiveKitService.URL,
+	})
+}
+
+// HandleWebhook recebe os eventos do LiveKit e mantém models.Session em dia
+func (h *RTCHandler) HandleWebhook(c *gin.Context) {
+	if h.LiveKitService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "livekit_not_configured"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body"})
+		return
+	}
+	event, err := h.LiveKitService.VerifyWebhook(body, c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if event.Room == nil || event.Room.Name == "" {
+		c.Status(http.StatusOK)
+		return
+	}
+	roomID := event.Room.Name
+
+	switch event.Event {
+	case "room_started":
+		members, _ := h.MatchService.RoomMembers(roomID)
+		if len(members) == 0 {
+			break
+		}
+		_, err = h.SessionService.Start(roomID, event.Time(), members...)
+	case "participant_joined":
+		if event.Participant == nil {
+			break
+		}
+		_, err = h.SessionService.Join(roomID, event.Participant.Identity, event.Time())
+	case "participant_left":
+		if event.Participant == nil {
+			break
+		}
+		h.notifyParticipantLeft(roomID, event.Participant.Identity)
+	case "room_finished":
+		err = h.SessionService.End(roomID, event.Time())
+	}
+
+	if err != nil {
+		log.Printf("❌ Webhook %s for %s failed: %v", event.Event, roomID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook_processing_failed"})
+		return
+	}
+	log.Printf("📹 LiveKit %s in %s", event.Event, roomID)
+	c.Status(http.StatusOK)
+}
+
+// notifyParticipantLeft avisa o(s) outro(s) membro(s) da sala que a mídia do parceiro caiu
+func (h *RTCHandler) notifyParticipantLeft(roomID, leaverID string) {
+	members, err := h.MatchService.RoomMembers(roomID)
+	if err != nil {
+		return
+	}
+	for _, id := range members {
+		if id == leaverID {
+			continue
+		}
+		h.WS.sendTo(id, WSMessage{Type: "partner_media_left", Payload: h.WS.mustMarshal(gin.H{
+			"room_id":    roomID,
+			"partner_id": leaverID,
+		})})
+	}
+}
+
+// provisionRoom registra a sala no Redis e cria a sala correspondente no LiveKit
+func provisionRoom(ms *services.MatchService, lk *services.LiveKitService, roomID string, capacity int, userIDs ...string) {
+	if err := ms.RegisterRoom(roomID, userIDs...); err != nil {
+		log.Printf("❌ Failed to register room %s: %v", roomID, err)
+	}
+	createLiveKitRoom(lk, roomID, capacity)
+}
+
+// createLiveKitRoom cria a sala no LiveKit em background; sem LiveKit não faz nada
+func createLiveKitRoom(lk *services.LiveKitService, roomID string, capacity int) {
+	if lk == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := lk.CreateRoom(ctx, roomID, capacity); err != nil {
+			log.Printf("❌ LiveKit room %s not provisioned: %v", roomID, err)
+		}
+	}()
+}
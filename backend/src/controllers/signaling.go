@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limites de sinalização WebRTC
+const (
+	maxSignalPayload   = 96 * 1024
+	maxSDPSize         = 64 * 1024
+	maxCandidateSize   = 1024
+	maxICERestarts     = 3
+	negotiationTimeout = 15 * time.Second
+)
+
+var (
+	errSignalTooLarge   = errors.New("payload_too_large")
+	errSignalMalformed  = errors.New("malformed_payload")
+	errSignalWrongType  = errors.New("sdp_type_mismatch")
+	errSignalInvalidSDP = errors.New("invalid_sdp")
+	errSignalInvalidICE = errors.New("invalid_candidate")
+)
+
+type sessionDescription struct {
+	Type string `json:"type"`
+	SDP  string `json:"sdp"`
+}
+
+type iceCandidate struct {
+	Candidate        string  `json:"candidate"`
+	SDPMid           *string `json:"sdpMid"`
+	SDPMLineIndex    *int    `json:"sdpMLineIndex"`
+	UsernameFragment *string `json:"usernameFragment,omitempty"`
+}
+
+// handleSignal valida e repassa offer/answer/ice apenas ao parceiro da sala
+func (h *WSHandler) handleSignal(senderID, msgType string, payload json.RawMessage) {
+	// Mesas de grupo usam apenas o LiveKit para mídia
+	room, partnerID := h.findRoom(senderID)
+	if room == nil || partnerID == "" {
+		return
+	}
+
+	clean, err := validateSignal(msgType, payload)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s from %s: %v", msgType, senderID, err)
+		h.sendTo(senderID, WSMessage{Type: "signal_error", Payload: h.mustMarshal(gin.H{
+			"type":  msgType,
+			"error": err.Error(),
+		})})
+		return
+	}
+
+	switch msgType {
+	case "webrtc_offer":
+		h.startNegotiationTimer(room, senderID)
+	case "webrtc_answer":
+		h.stopNegotiationTimer(room)
+		// Negociação concluída: uma queda futura volta a ter todas as tentativas
+		h.mu.Lock()
+		room.ICERestarts = 0
+		h.mu.Unlock()
+	}
+
+	h.sendTo(partnerID, WSMessage{Type: msgType, Payload: clean})
+}
+
+// handleICEFailure coordena o ICE restart: o primeiro membro da sala sempre gera a nova offer
+// para evitar glare; após maxICERestarts a negociação é dada como perdida.
+func (h *WSHandler) handleICEFailure(senderID string, payload json.RawMessage) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	json.Unmarshal(payload, &input)
+
+	room, partnerID := h.findRoom(senderID)
+	if room == nil || partnerID == "" {
+		return
+	}
+
+	h.mu.Lock()
+	room.ICERestarts++
+	attempt := room.ICERestarts
+	initiator := room.Members[0]
+	h.mu.Unlock()
+
+	log.Printf("❄️ ICE failure reported by %s in %s (attempt %d, reason: %s)", senderID, room.ID, attempt, input.Reason)
+
+	if attempt > maxICERestarts {
+		h.stopNegotiationTimer(room)
+		failed := WSMessage{Type: "ice_failed", Payload: h.mustMarshal(gin.H{
+			"room_id":  room.ID,
+			"attempts": attempt - 1,
+		})}
+		h.sendTo(senderID, failed)
+		h.sendTo(partnerID, failed)
+		return
+	}
+
+	for _, id := range []string{senderID, partnerID} {
+		h.sendTo(id, WSMessage{Type: "ice_restart", Payload: h.mustMarshal(gin.H{
+			"room_id":   room.ID,
+			"attempt":   attempt,
+			"max":       maxICERestarts,
+			"initiator": id == initiator,
+		})})
+	}
+}
+
+func (h *WSHandler) startNegotiationTimer(room *Room, offererID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room.negotiation != nil {
+		room.negotiation.Stop()
+	}
+	roomID := room.ID
+	room.negotiation = time.AfterFunc(negotiationTimeout, func() {
+		h.mu.Lock()
+		current, ok := h.rooms[roomID]
+		if ok && current == room {
+			room.negotiation = nil
+		}
+		h.mu.Unlock()
+
+		if !ok || current != room {
+			return
+		}
+		log.Printf("⏰ Negotiation timeout for %s in %s", offererID, roomID)
+		h.sendTo(offererID, WSMessage{Type: "negotiation_timeout", Payload: h.mustMarshal(gin.H{"room_id": roomID})})
+	})
+}
+
+func (h *WSHandler) stopNegotiationTimer(room *Room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room.negotiation != nil {
+		room.negotiation.Stop()
+		room.negotiation = nil
+	}
+}
+
+// validateSignal checa o formato e o tamanho do payload e devolve uma versão
+// re-serializada contendo apenas os campos conhecidos.
+func validateSignal(msgType string, payload json.RawMessage) (json.RawMessage, error) {
+	if len(payload) == 0 {
+		return nil, errSignalMalformed
+	}
+	if len(payload) > maxSignalPayload {
+		return nil, errSignalTooLarge
+	}
+
+	switch msgType {
+	case "webrtc_offer", "webrtc_answer":
+		var input struct {
+			SDP *sessionDescription `json:"sdp"`
+		}
+		if err := json.Unmarshal(payload, &input); err != nil || input.SDP == nil {
+			return nil, errSignalMalformed
+		}
+		if strings.TrimPrefix(msgType, "webrtc_") != input.SDP.Type {
+			return nil, errSignalWrongType
+		}
+		if len(input.SDP.SDP) > maxSDPSize {
+			return nil, errSignalTooLarge
+		}
+		if !strings.HasPrefix(input.SDP.SDP, "v=0") || !strings.Contains(input.SDP.SDP, "m=") {
+			return nil, errSignalInvalidSDP
+		}
+		return json.Marshal(gin.H{"sdp": input.SDP})
+
+	case "webrtc_ice":
+		var input struct {
+			Candidate *iceCandidate `json:"candidate"`
+		}
+		if err := json.Unmarshal(payload, &input); err != nil || input.Candidate == nil {
+			return nil, errSignalMalformed
+		}
+		c := input.Candidate
+		if len(c.Candidate) > maxCandidateSize {
+			return nil, errSignalTooLarge
+		}
+		// Candidate vazio sinaliza end-of-candidates e é válido
+		if c.Candidate != "" && !strings.HasPrefix(c.Candidate, "candidate:") {
+			return nil, errSignalInvalidICE
+		}
+		if c.SDPMid == nil && c.SDPMLineIndex == nil {
+			return nil, errSignalInvalidICE
+		}
+		return json.Marshal(gin.H{"candidate": c})
+	}
+
+	return nil, errSignalMalformed
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type UsageHandler struct {
+	UsageService *services.UsageService
+}
+
+// HandleUsageReport mostra o consumo de tradução de um dia (rota administrativa);
+// aceita ?date=2006-01-02 (padrão hoje, UTC) e ?limit= para o ranking de usuários
+func (h *UsageHandler) HandleUsageReport(c *gin.Context) {
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_date"})
+			return
+		}
+	}
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+
+	report, err := h.UsageService.Report(c.Request.Context(), date, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "usage_lookup_failed"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// notifyQuota avisa só o autor; os destinatários recebem a mensagem sem
+// tradução, com translation_status "quota_exceeded"
+func (h *WSHandler) notifyQuota(userID, messageID string, quota *services.QuotaError) {
+	payload := gin.H{
+		"message_id": messageID,
+		"scope":      quota.Scope,
+		"limit":      quota.Limit,
+		"used":       quota.Used,
+		"resets_at":  quota.ResetsAt.UnixMilli(),
+	}
+	if quota.Tier != "" {
+		payload["tier"] = quota.Tier
+	}
+	h.sendTo(userID, WSMessage{Type: "quota_exceeded", Payload: h.mustMarshal(payload)})
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/vox-bridge/nexus-core/src/services"
 	"gorm.io/gorm"
@@ -18,35 +21,92 @@ var upgrader = websocket.Upgrader{
 }
 
 type WSHandler struct {
-	TranslationService *services.TranslationService
-	MatchService       *services.MatchService
-	AuthService        *services.AuthService
-	DB                 *gorm.DB
+	Translator       services.Translator
+	LanguageDetector services.LanguageDetector
+	Coach            services.Coach
+	// Orçamento de tokens do contexto de tradução por sala (0 desliga)
+	ContextTokens     int
+	MatchService      *services.MatchService
+	AuthService       *services.AuthService
+	LiveKitService    *services.LiveKitService
+	BlockService      *services.BlockService
+	ReputationService *services.ReputationService
+	GroupService      *services.GroupService
+	SessionService    *services.SessionService
+	RatingService     *services.RatingService
+	GlossaryService   *services.GlossaryService
+	UsageService      *services.UsageService
+	DB                *gorm.DB
+
+	// Identifica esta instância no Redis (dona de salas, presença, relay)
+	InstanceID string
 
 	// Active connections
-	connections map[string]*websocket.Conn
-	rooms       map[string]*Room
-	mu          sync.RWMutex
+	connections map[string]*wsConn
+	// Conexões que pediram tradução em streaming (?stream=1)
+	streaming map[string]bool
+	rooms     map[string]*Room
+	mu        sync.RWMutex
 }
 
+// wsConn serializa as escritas de um socket (gorilla/websocket permite apenas
+// um escritor por vez) sem que um cliente lento segure os demais
+type wsConn struct {
+	*websocket.Conn
+	writeMu sync.Mutex
+}
+
+// Room é uma conversa em pares (matchmaking) ou uma mesa de grupo (join_group).
+// Members[0] é quem inicia o ICE restart nas salas em pares.
 type Room struct {
-	ID    string
-	User1 string
-	User2 string
+	ID        string
+	Members   []string
+	Group     bool
+	SessionID string
+
+	// Idiomas de cada membro; nas salas em pares também recolocam os dois na fila no "next"
+	requests  map[string]services.MatchRequest
+	startedAt time.Time
+
+	// Modo de exibição do chat escolhido por cada membro (original | translated | both)
+	display map[string]string
+	// Último idioma detectado nas mensagens de cada membro
+	spoken map[string]string
+	// Membros com o modo coach ligado
+	coach map[string]bool
+	// Revisões do coach em andamento (ver roomCoachSlots)
+	coaching chan struct{}
+	// Mensagens recentes enviadas como contexto à tradução; some junto com a sala
+	history *services.ConversationWindow
+	// Glossário de cada membro, lido do banco uma vez (ver roomGlossary)
+	glossaries map[string][]services.GlossaryTerm
+	// Traduções aceitas e semáforo das em andamento (ver roomTranslationSlots)
+	translationQueue chan struct{}
+	translating      chan struct{}
+
+	// Estado da negociação WebRTC
+	ICERestarts int
+	negotiation *time.Timer
 }
 
 type WSMessage struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload,omitempty"`
+	// Sessão do bot a que a mensagem se refere (ver services.BotSessionOwner);
+	// a conexão do bot atende todas as suas salas
+	Session string `json:"session,omitempty"`
 }
 
-func NewWSHandler(ts *services.TranslationService, ms *services.MatchService, as *services.AuthService) *WSHandler {
+func NewWSHandler(t services.Translator, ms *services.MatchService, as *services.AuthService) *WSHandler {
 	return &WSHandler{
-		TranslationService: ts,
-		MatchService:       ms,
-		AuthService:        as,
-		connections:        make(map[string]*websocket.Conn),
-		rooms:              make(map[string]*Room),
+		Translator:    t,
+		MatchService:  ms,
+		AuthService:   as,
+		ContextTokens: services.DefaultContextTokens,
+		InstanceID:    uuid.New().String(),
+		connections:   make(map[string]*wsConn),
+		streaming:     make(map[string]bool),
+		rooms:         make(map[string]*Room),
 	}
 }
 
@@ -64,21 +124,46 @@ func (h *WSHandler) HandleWS(c *gin.Context) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("❌ WS Upgrade failed: %v", err)
 		return
 	}
+	conn := &wsConn{Conn: ws}
+
+	stream, _ := strconv.ParseBool(c.Query("stream"))
 
 	h.mu.Lock()
 	h.connections[claims.UserID] = conn
+	h.streaming[claims.UserID] = stream
 	h.mu.Unlock()
+	if err := h.MatchService.SetOnline(claims.UserID, h.InstanceID); err != nil {
+		log.Printf("⚠️ Presence not stored for %s: %v", claims.UserID, err)
+	}
 
 	defer func() {
+		conn.Close()
+		// Se o usuário reconectou, a entrada já é da conexão nova e nada é desfeito
 		h.mu.Lock()
-		delete(h.connections, claims.UserID)
+		current := h.connections[claims.UserID] == conn
+		if current {
+			delete(h.connections, claims.UserID)
+			delete(h.streaming, claims.UserID)
+		}
 		h.mu.Unlock()
-		conn.Close()
+		if !current {
+			return
+		}
+
+		// Usuário desconectado não pode continuar na fila nem prender o parceiro numa sala morta
+		h.MatchService.ClearOnline(claims.UserID, h.InstanceID)
+		h.MatchService.RemoveFromQueue(claims.UserID)
+		if !h.relayToOwner(claims.UserID, services.RelayMessage{Disconnect: true}) {
+			h.closeRoom(claims.UserID, "disconnected")
+		}
+		for _, session := range h.botSessions(claims.UserID) {
+			h.closeRoom(session, "disconnected")
+		}
 	}()
 
 	// Welcome message
@@ -95,12 +180,30 @@ func (h *WSHandler) HandleWS(c *gin.Context) {
 			continue
 		}
 
-		h.handleMessage(claims.UserID, msg)
+		userID := claims.UserID
+		if msg.Session != "" {
+			if !h.ownsBotSession(claims.UserID, msg.Session) {
+				continue
+			}
+			userID = msg.Session
+		}
+		if roomMessages[msg.Type] && h.relayToOwner(userID, services.RelayMessage{Type: msg.Type, Payload: msg.Payload}) {
+			continue
+		}
+		h.handleMessage(userID, msg)
 	}
 }
 
 func (h *WSHandler) handleMessage(userID string, msg WSMessage) {
 	switch msg.Type {
+	case "join_group":
+		h.handleJoinGroup(userID, msg.Payload)
+	case "leave_group":
+		h.closeRoom(userID, "left")
+	case "set_display_mode":
+		h.handleDisplayMode(userID, msg.Payload)
+	case "set_coach_mode":
+		h.handleCoachMode(userID, msg.Payload)
 	case "join_queue":
 		h.handleJoinQueue(userID, msg.Payload)
 	case "leave_queue":
@@ -111,12 +214,23 @@ func (h *WSHandler) handleMessage(userID string, msg WSMessage) {
 		h.handleTyping(userID, true)
 	case "stop_typing":
 		h.handleTyping(userID, false)
+	case "webrtc_offer", "webrtc_answer", "webrtc_ice":
+		h.handleSignal(userID, msg.Type, msg.Payload)
+	case "next":
+		h.handleNext(userID)
+	case "report_user":
+		h.handleReportUser(userID, msg.Payload)
+	case "rate_partner":
+		h.handleRatePartner(userID, msg.Payload)
+	case "block_user":
+		h.handleBlockUser(userID, msg.Payload)
+	case "ice_failure":
+		h.handleICEFailure(userID, msg.Payload)
 	case "ping":
 		h.mu.RLock()
-		if conn, ok := h.connections[userID]; ok {
-			h.sendJSON(conn, WSMessage{Type: "pong", Payload: h.mustMarshal(gin.H{"online": len(h.connections)})})
-		}
+		online := len(h.connections)
 		h.mu.RUnlock()
+		h.sendTo(userID, WSMessage{Type: "pong", Payload: h.mustMarshal(gin.H{"online": online})})
 	}
 }
 
@@ -124,122 +238,266 @@ func (h *WSHandler) handleJoinQueue(userID string, payload json.RawMessage) {
 	var req services.MatchRequest
 	json.Unmarshal(payload, &req)
 	req.UserID = userID
+	h.joinQueue(req)
+}
 
+func (h *WSHandler) joinQueue(req services.MatchRequest) {
+	userID := req.UserID
 	log.Printf("📥 User %s joining queue (%s -> %s)", userID, req.NativeLanguage, req.TargetLanguage)
-	h.MatchService.AddToQueue(req)
+	result, err := h.MatchService.MatchOrEnqueue(req)
+	if err != nil {
+		log.Printf("❌ Matchmaking failed for %s: %v", userID, err)
+		h.sendTo(userID, WSMessage{Type: "queue_error", Payload: h.mustMarshal(gin.H{"error": "matchmaking_unavailable"})})
+		return
+	}
 
-	h.mu.RLock()
-	if conn, ok := h.connections[userID]; ok {
-		h.sendJSON(conn, WSMessage{Type: "queue_joined"})
+	if result.Status == services.MatchStatusMatched {
+		h.openRoom(req, *result.Partner, result.RoomID)
+		return
 	}
-	h.mu.RUnlock()
 
-	// Perform background matchmaking
-	go h.attemptMatch(req)
+	joined := gin.H{}
+	if state, err := h.MatchService.Status(context.Background(), userID); err == nil && state != nil {
+		joined["position"] = state.Position
+		joined["eta_seconds"] = state.ETASeconds
+	}
+	if result.Cooldown {
+		joined["reason"] = "recent_partner_cooldown"
+		joined["message"] = "Only people you just talked to are available right now. Waiting for someone new."
+	}
+	h.sendTo(userID, WSMessage{Type: "queue_joined", Payload: h.mustMarshal(joined)})
 }
 
 func (h *WSHandler) handleLeaveQueue(userID string) {
-	// Implement removal from Redis if needed
-	h.mu.RLock()
-	if conn, ok := h.connections[userID]; ok {
-		h.sendJSON(conn, WSMessage{Type: "queue_left"})
+	if err := h.MatchService.RemoveFromQueue(userID); err != nil {
+		log.Printf("❌ Failed to remove %s from queue: %v", userID, err)
 	}
-	h.mu.RUnlock()
+
+	h.sendTo(userID, WSMessage{Type: "queue_left"})
 }
 
-func (h *WSHandler) attemptMatch(req services.MatchRequest) {
-	partner, err := h.MatchService.FindMatch(req)
-	if err != nil || partner == nil {
+// openRoom cria a sala para um par já removido da fila e notifica os dois.
+// Só a instância que reivindica a sala a abre; as outras falam com ela pelo relay.
+func (h *WSHandler) openRoom(req, partner services.MatchRequest, roomID string) {
+	if owned, err := h.MatchService.ClaimRoom(roomID, h.InstanceID); err != nil {
+		log.Printf("⚠️ Failed to claim room %s: %v", roomID, err)
+	} else if !owned {
 		return
 	}
-
-	roomID := "room_" + req.UserID + "_" + partner.UserID
+	req, partner = h.withProfileLanguages(req), h.withProfileLanguages(partner)
 	room := &Room{
-		ID:    roomID,
-		User1: req.UserID,
-		User2: partner.UserID,
+		ID:      roomID,
+		Members: []string{req.UserID, partner.UserID},
+		requests: map[string]services.MatchRequest{
+			req.UserID:     req,
+			partner.UserID: partner,
+		},
+		startedAt: time.Now(),
+	}
+
+	if h.SessionService != nil {
+		if session, err := h.SessionService.Start(roomID, room.startedAt, req.UserID, partner.UserID); err == nil {
+			room.SessionID = session.ID
+		} else {
+			log.Printf("⚠️ Failed to start session for %s: %v", roomID, err)
+		}
 	}
 
 	h.mu.Lock()
 	h.rooms[roomID] = room
 	h.mu.Unlock()
 
+	provisionRoom(h.MatchService, h.LiveKitService, roomID, 2, req.UserID, partner.UserID)
+
 	// Notify both partners
-	h.notifyMatch(req.UserID, partner.UserID, roomID)
-	h.notifyMatch(partner.UserID, req.UserID, roomID)
+	common := services.CommonInterests(req, partner)
+	h.notifyMatch(req, partner, room, common)
+	h.notifyMatch(partner, req, room, common)
 }
 
-func (h *WSHandler) notifyMatch(userID, partnerID, roomID string) {
+// closeRoom encerra a sala do usuário e avisa o parceiro (em grupos, só tira o usuário da mesa)
+func (h *WSHandler) closeRoom(userID, reason string) {
+	room, partnerID := h.findRoom(userID)
+	if room == nil {
+		return
+	}
+	if room.Group {
+		h.leaveGroup(room, userID, reason)
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.rooms, room.ID)
+	// O contexto de tradução não sobrevive à sala
+	room.history = nil
+	if room.negotiation != nil {
+		room.negotiation.Stop()
+		room.negotiation = nil
+	}
+	h.mu.Unlock()
+
+	h.MatchService.ReleaseRoom(room.ID, room.Members...)
+	go h.settleReputation(room, userID, reason)
+	if h.SessionService != nil {
+		go h.SessionService.End(room.ID, time.Now())
+	}
+	// session_id permite avaliar o parceiro (rate_partner) depois que a sala fecha
+	h.sendTo(partnerID, WSMessage{Type: "partner_left", Payload: h.mustMarshal(gin.H{
+		"room_id":    room.ID,
+		"session_id": room.SessionID,
+		"reason":     reason,
+	})})
+	log.Printf("🚪 Room %s closed (%s)", room.ID, reason)
+}
+
+// handleNext encerra a conversa atual e recoloca os dois na fila; o cooldown
+// de parceiros recentes impede que eles sejam pareados de novo em seguida
+func (h *WSHandler) handleNext(userID string) {
+	room, partnerID := h.findRoom(userID)
+	if room == nil || room.Group {
+		return
+	}
+	h.mu.RLock()
+	own, partner := room.requests[userID], room.requests[partnerID]
+	h.mu.RUnlock()
+
+	h.closeRoom(userID, "next")
+
+	// O parceiro volta primeiro: ele não escolheu sair e não deve perder a vez
+	if partner.UserID != "" && !partner.Bot && h.isOnline(partnerID) {
+		h.joinQueue(partner)
+	}
+	own.UserID = userID
+	h.joinQueue(own)
+}
+
+func (h *WSHandler) notifyMatch(user, partner services.MatchRequest, room *Room, commonInterests []string) {
+	h.sendTo(user.UserID, WSMessage{Type: "matched", Payload: h.mustMarshal(gin.H{
+		"room_id":    room.ID,
+		"session_id": room.SessionID,
+		"partner": gin.H{
+			"id":           partner.UserID,
+			"anonymous_id": peerName(partner.UserID),
+			"language":     partner.NativeLanguage,
+			"country":      partner.Country,
+			"bot":          partner.Bot,
+		},
+		"mode":             services.MatchMode(user, partner),
+		"common_interests": commonInterests,
+	})})
+}
+
+func peerName(userID string) string {
+	if len(userID) > 4 {
+		userID = userID[:4]
+	}
+	return "NexusPeer_" + userID
+}
+
+func (h *WSHandler) handleTyping(userID string, isTyping bool) {
+	// Send typing status to partner
+}
+
+// findRoom retorna a sala do usuário e o ID do parceiro (vazio em mesas de grupo)
+func (h *WSHandler) findRoom(userID string) (*Room, string) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	if conn, ok := h.connections[userID]; ok {
-		h.sendJSON(conn, WSMessage{Type: "matched", Payload: h.mustMarshal(gin.H{
-			"room_id": roomID,
-			"partner": gin.H{
-				"id":           partnerID,
-				"anonymous_id": "NexusPeer_" + partnerID[:4],
-			},
-		})})
+	for _, r := range h.rooms {
+		if !r.has(userID) {
+			continue
+		}
+		if r.Group {
+			return r, ""
+		}
+		others := r.others(userID)
+		if len(others) == 0 {
+			return r, ""
+		}
+		return r, others[0]
 	}
+	return nil, ""
 }
 
-func (h *WSHandler) handleChat(senderID string, payload json.RawMessage) {
-	var input struct {
-		Text string `json:"text"`
+// ownsBotSession diz se a conexão de userID pode falar pela sessão: só o bot
+// configurado, e só por sessões que o servidor abriu para uma sala ainda aberta
+func (h *WSHandler) ownsBotSession(userID, session string) bool {
+	relax := h.MatchService.Relaxation
+	if relax == nil || relax.BotUserID == "" || userID != relax.BotUserID || services.BotSessionOwner(session) != userID {
+		return false
 	}
-	json.Unmarshal(payload, &input)
+	roomID, err := h.MatchService.CurrentRoom(session)
+	return err == nil && roomID != ""
+}
 
-	// Find room
-	var room *Room
-	var partnerID string
+// botSessions lista as sessões do bot userID nas salas desta instância
+func (h *WSHandler) botSessions(userID string) []string {
 	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var sessions []string
 	for _, r := range h.rooms {
-		if r.User1 == senderID {
-			room = r
-			partnerID = r.User2
-			break
-		} else if r.User2 == senderID {
-			room = r
-			partnerID = r.User1
-			break
+		for _, id := range r.Members {
+			if services.BotSessionOwner(id) == userID {
+				sessions = append(sessions, id)
+			}
 		}
 	}
-	h.mu.RUnlock()
+	return sessions
+}
 
-	if room == nil {
-		return
+func (r *Room) has(userID string) bool {
+	for _, id := range r.Members {
+		if id == userID {
+			return true
+		}
 	}
+	return false
+}
 
-	// Translation Logic
-	translated := input.Text
-	// In a real scenario, we would determine languages from user profiles
-	// Simplified: auto-translate if bridge is active
-	if h.TranslationService != nil {
-		trans, err := h.TranslationService.Translate(input.Text, "auto", "en")
-		if err == nil {
-			translated = trans
+// others lista os demais membros; chamar com h.mu travado
+func (r *Room) others(userID string) []string {
+	out := make([]string, 0, len(r.Members))
+	for _, id := range r.Members {
+		if id != userID {
+			out = append(out, id)
 		}
 	}
+	return out
+}
+
+// sendTo entrega a mensagem ao usuário, nesta instância ou via relay
+func (h *WSHandler) sendTo(userID string, msg WSMessage) {
+	if h.sendLocal(userID, msg) {
+		return
+	}
+	relay := services.RelayMessage{To: userID, Type: msg.Type, Payload: msg.Payload}
+	if err := h.MatchService.PublishRelay(context.Background(), relay); err != nil {
+		log.Printf("❌ Failed to relay %s to %s: %v", msg.Type, userID, err)
+	}
+}
 
-	h.mu.RLock()
-	if conn, ok := h.connections[partnerID]; ok {
-		h.sendJSON(conn, WSMessage{Type: "chat_message", Payload: h.mustMarshal(gin.H{
-			"from":            senderID,
-			"text":            input.Text,
-			"translated_text": translated,
-			"timestamp":       time.Now().UnixMilli(),
-		})})
+// sendLocal envia a mensagem se o usuário estiver conectado a esta instância;
+// a escrita acontece fora de h.mu
+func (h *WSHandler) sendLocal(userID string, msg WSMessage) bool {
+	connID := userID
+	if bot := services.BotSessionOwner(userID); bot != "" {
+		connID, msg.Session = bot, userID
 	}
+	h.mu.RLock()
+	conn, ok := h.connections[connID]
 	h.mu.RUnlock()
-}
 
-func (h *WSHandler) handleTyping(userID string, isTyping bool) {
-	// Send typing status to partner
+	if ok {
+		h.sendJSON(conn, msg)
+	}
+	return ok
 }
 
-func (h *WSHandler) sendJSON(conn *websocket.Conn, msg WSMessage) {
+func (h *WSHandler) sendJSON(conn *wsConn, msg WSMessage) {
 	data, _ := json.Marshal(msg)
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
 	conn.WriteMessage(websocket.TextMessage, data)
 }
 
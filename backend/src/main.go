@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -26,7 +29,7 @@ func main() {
 	}
 
 	// Auto-migrate tables
-	db.AutoMigrate(&models.User{}, &models.Session{}, &models.Report{})
+	db.AutoMigrate(&models.User{}, &models.Session{}, &models.Report{}, &models.Block{}, &models.ReputationEvent{}, &models.Rating{}, &models.GlossaryEntry{})
 	log.Println("Database migrated successfully")
 
 	// Initialize Redis
@@ -45,28 +48,109 @@ func main() {
 	}
 	authService := &services.AuthService{DB: db, JWTSecret: jwtSecret}
 	matchService := &services.MatchService{Redis: rdb}
-	translationService := services.NewTranslationService()
+	matchService.RecentCooldown = 10 * time.Minute
+	if v, err := time.ParseDuration(os.Getenv("MATCH_RECENT_COOLDOWN")); err == nil {
+		matchService.RecentCooldown = v
+	}
+	blockService := &services.BlockService{DB: db, Match: matchService}
+	matchService.Blocks = blockService
+	reputationService := &services.ReputationService{DB: db}
+	matchService.Reputation = reputationService
+	spokenAfter, _ := time.ParseDuration(os.Getenv("MATCH_RELAX_SPOKEN_AFTER"))
+	oneWayAfter, _ := time.ParseDuration(os.Getenv("MATCH_RELAX_ONEWAY_AFTER"))
+	if spokenAfter > 0 || oneWayAfter > 0 {
+		matchService.Relaxation = &services.RelaxationPolicy{
+			SpokenAfter: spokenAfter,
+			OneWayAfter: oneWayAfter,
+			BotUserID:   os.Getenv("MATCH_BOT_USER_ID"),
+		}
+	}
+	translator := services.NewTranslator()
+	var translationCache *services.CachedTranslator
+	if translator != nil && os.Getenv("TRANSLATION_CACHE") != "off" {
+		translationCache = &services.CachedTranslator{Next: translator, Redis: rdb}
+		translationCache.TTL, _ = time.ParseDuration(os.Getenv("TRANSLATION_CACHE_TTL"))
+		translationCache.MaxEntries, _ = strconv.ParseInt(os.Getenv("TRANSLATION_CACHE_MAX_ENTRIES"), 10, 64)
+		translator = translationCache
+	}
+	usageService := services.NewUsageService(rdb, authService)
+	if translator != nil {
+		// Glossário por fora do cache: o cache vê o texto com marcadores
+		translator = &services.GlossaryTranslator{Next: translator}
+		// Cota por fora de tudo: acertos de cache também contam caracteres
+		translator = &services.MeteredTranslator{Next: translator, Usage: usageService}
+	}
+	turnService := services.NewTURNService()
+	liveKitService := services.NewLiveKitService()
+	sessionService := &services.SessionService{DB: db}
+	groupService := &services.GroupService{Redis: rdb}
+	if v, err := strconv.Atoi(os.Getenv("GROUP_ROOM_CAPACITY")); err == nil {
+		groupService.Capacity = v
+	}
+	ratingService := &services.RatingService{DB: db, Reputation: reputationService}
+	glossaryService := &services.GlossaryService{DB: db}
+
+	wsHandler := controllers.NewWSHandler(translator, matchService, authService)
+	wsHandler.DB = db
+	wsHandler.LanguageDetector = services.NewLanguageDetector()
+	wsHandler.Coach = services.NewCoach(translator)
+	if v, err := strconv.Atoi(os.Getenv("TRANSLATION_CONTEXT_TOKENS")); err == nil && v >= 0 {
+		wsHandler.ContextTokens = v
+	}
+	wsHandler.LiveKitService = liveKitService
+	wsHandler.BlockService = blockService
+	wsHandler.ReputationService = reputationService
+	wsHandler.GroupService = groupService
+	wsHandler.SessionService = sessionService
+	wsHandler.RatingService = ratingService
+	wsHandler.GlossaryService = glossaryService
+	wsHandler.UsageService = usageService
 
 	handler := &controllers.NexusHandler{
-		AuthService:  authService,
-		MatchService: matchService,
+		AuthService:    authService,
+		MatchService:   matchService,
+		LiveKitService: liveKitService,
+		WS:             wsHandler,
 	}
 
-	wsHandler := controllers.NewWSHandler(translationService, matchService, authService)
-	wsHandler.DB = db
+	blockHandler := &controllers.BlockHandler{BlockService: blockService}
+	moderationHandler := &controllers.ModerationHandler{ReputationService: reputationService}
+	ratingHandler := &controllers.RatingHandler{RatingService: ratingService}
+	glossaryHandler := &controllers.GlossaryHandler{GlossaryService: glossaryService, WS: wsHandler}
+	usageHandler := &controllers.UsageHandler{UsageService: usageService}
+	healthHandler := &controllers.HealthHandler{
+		Translator:       translator,
+		TranslationCache: translationCache,
+		Coach:            wsHandler.Coach,
+	}
+
+	rtcHandler := &controllers.RTCHandler{
+		TURNService:    turnService,
+		LiveKitService: liveKitService,
+		MatchService:   matchService,
+		SessionService: sessionService,
+		WS:             wsHandler,
+	}
+
+	// Matchmaker em background (eleição de líder via Redis)
+	sweepInterval := 2 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("MATCH_SWEEP_INTERVAL")); err == nil && v > 0 {
+		sweepInterval = v
+	}
+	go wsHandler.RunMatchmaker(context.Background(), sweepInterval)
+	go wsHandler.RunRelay(context.Background())
 
 	r := gin.Default()
 
 	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok", "neural_bridge": translationService != nil})
-	})
+	r.GET("/health", healthHandler.HandleHealth)
 
 	// Public Routes
 	v1 := r.Group("/v1")
 	{
 		v1.POST("/auth/anonymous", handler.HandleAnonymousAuth)
 		v1.GET("/ws", wsHandler.HandleWS)
+		v1.POST("/rtc/webhook", rtcHandler.HandleWebhook)
 	}
 
 	// Private Routes
@@ -74,6 +158,25 @@ func main() {
 	authorized.Use(middleware.AuthRequired(jwtSecret))
 	{
 		authorized.POST("/match/join", handler.HandleJoinQueue)
+		authorized.GET("/match/status", handler.HandleMatchStatus)
+		authorized.GET("/rtc/ice-servers", rtcHandler.HandleICEServers)
+		authorized.GET("/rtc/token", rtcHandler.HandleToken)
+		authorized.GET("/blocks", blockHandler.HandleListBlocks)
+		authorized.DELETE("/blocks/:id", blockHandler.HandleUnblock)
+		authorized.POST("/sessions/:id/rating", ratingHandler.HandleRateSession)
+		authorized.GET("/users/:id/ratings", ratingHandler.HandleUserRatings)
+		authorized.GET("/glossary", glossaryHandler.HandleListGlossary)
+		authorized.POST("/glossary", glossaryHandler.HandleCreateGlossaryEntry)
+		authorized.PUT("/glossary/:id", glossaryHandler.HandleUpdateGlossaryEntry)
+		authorized.DELETE("/glossary/:id", glossaryHandler.HandleDeleteGlossaryEntry)
+	}
+
+	// Admin Routes
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AdminRequired(os.Getenv("ADMIN_TOKEN")))
+	{
+		admin.POST("/reports/:id/uphold", moderationHandler.HandleUpholdReport)
+		admin.GET("/usage", usageHandler.HandleUsageReport)
 	}
 
 	port := os.Getenv("PORT")
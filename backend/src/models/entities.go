@@ -18,6 +18,8 @@ type User struct {
 	TargetLanguage string    `json:"target_language"`
 	Reputation     float64   `gorm:"default:100.0" json:"reputation"`
 	IsBanned       bool      `gorm:"default:false" json:"is_banned"`
+	// Plano do usuário; define a cota diária de tradução
+	Tier           string    `gorm:"default:'free'" json:"tier"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -27,11 +29,19 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
 // Session registra métricas de tradução e IA
 type Session struct {
 	ID               string     `gorm:"primaryKey;type:uuid" json:"id"`
 	UserID           string     `gorm:"not null" json:"user_id"`
 	User             User       `gorm:"foreignKey:UserID" json:"-"`
+	PartnerID        string     `gorm:"index" json:"partner_id"`
 	StartTime        time.Time  `gorm:"default:now()" json:"start_time"`
 	EndTime          *time.Time `json:"end_time"`
 	RoomID           string     `gorm:"uniqueIndex;not null" json:"room_id"`
@@ -46,5 +56,91 @@ type Report struct {
 	ReporterID     string    `json:"reporter_id"`
 	ReportedUserID string    `json:"reported_user_id"`
 	Reason         string    `json:"reason"`
-	AiEvidence     string    `gorm:"type:jsonb" json:"ai_evidence"` // Flags de moderação IA
+	Details        string    `json:"details,omitempty"`
+	AiEvidence     string    `gorm:"type:jsonb;default:'{}'" json:"ai_evidence"` // Flags de moderação IA
+	Status         string    `gorm:"default:'open'" json:"status"`               // open | upheld
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	// "" não é JSON válido para a coluna jsonb
+	if r.AiEvidence == "" {
+		r.AiEvidence = "{}"
+	}
+	return
+}
+
+// Block impede que dois usuários sejam pareados novamente (vale nos dois sentidos)
+type Block struct {
+	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	BlockerID string    `gorm:"not null;uniqueIndex:idx_block_pair" json:"blocker_id"`
+	BlockedID string    `gorm:"not null;uniqueIndex:idx_block_pair;index" json:"blocked_id"`
+}
+
+func (b *Block) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+// ReputationEvent é uma entrada do ledger de reputação; User.Reputation é
+// derivado da soma (com decaimento no tempo) dessas entradas
+type ReputationEvent struct {
+	ID        string    `gorm:"primaryKey;type:uuid" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UserID    string    `gorm:"not null;uniqueIndex:idx_reputation_ref" json:"user_id"`
+	Reason    string    `gorm:"not null;uniqueIndex:idx_reputation_ref" json:"reason"`
+	RefID     string    `gorm:"not null;uniqueIndex:idx_reputation_ref" json:"ref_id"` // report, sala ou avaliação de origem
+	Delta     float64   `json:"delta"`
+}
+
+func (e *ReputationEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// Rating é a avaliação que um participante dá ao outro ao fim de uma sessão
+type Rating struct {
+	ID          string    `gorm:"primaryKey;type:uuid" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	SessionID   string    `gorm:"not null;uniqueIndex:idx_rating_rater" json:"session_id"`
+	RaterID     string    `gorm:"not null;uniqueIndex:idx_rating_rater" json:"rater_id"`
+	RatedUserID string    `gorm:"not null;index" json:"rated_user_id"`
+	Score       int       `gorm:"not null" json:"score"`
+	Tags        []string  `gorm:"serializer:json;type:jsonb" json:"tags,omitempty"`
+	Text        string    `json:"text,omitempty"`
+}
+
+func (r *Rating) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// GlossaryEntry é um termo do glossário de um usuário. Sem Translation o termo
+// nunca é traduzido (nomes, marcas, gírias); com Translation é um mapeamento
+// forçado para o par de idiomas.
+type GlossaryEntry struct {
+	ID             string    `gorm:"primaryKey;type:uuid" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UserID         string    `gorm:"not null;index" json:"user_id"`
+	Term           string    `gorm:"not null" json:"term"`
+	Translation    string    `json:"translation,omitempty"`
+	SourceLanguage string    `json:"source_lang,omitempty"`
+	TargetLanguage string    `json:"target_lang,omitempty"`
+}
+
+func (g *GlossaryEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	return
 }
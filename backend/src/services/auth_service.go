@@ -73,3 +73,12 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// Tier implementa TierSource para o UsageService
+func (s *AuthService) Tier(userID string) (string, error) {
+	var user models.User
+	if err := s.DB.Select("tier").First(&user, "id = ?", userID).Error; err != nil {
+		return "", err
+	}
+	return user.Tier, nil
+}
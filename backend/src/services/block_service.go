@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCannotBlockSelf = errors.New("cannot_block_self")
+
+// BlockService persiste bloqueios no Postgres e os propaga para o matchmaking
+type BlockService struct {
+	DB    *gorm.DB
+	Match *MatchService
+}
+
+func (s *BlockService) Block(blockerID, blockedID string) error {
+	if blockerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+
+	block := models.Block{BlockerID: blockerID, BlockedID: blockedID}
+	err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error
+	if err != nil {
+		return err
+	}
+	return s.Match.AddExclusion(blockerID, blockedID)
+}
+
+// Unblock remove o bloqueio feito por blockerID; um bloqueio no sentido
+// inverso continua valendo
+func (s *BlockService) Unblock(blockerID, blockedID string) (bool, error) {
+	result := s.DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.Block{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, id := range []string{blockerID, blockedID} {
+		ids, err := s.Exclusions(id)
+		if err != nil {
+			return true, err
+		}
+		if err := s.Match.SetExclusions(id, ids); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (s *BlockService) List(blockerID string) ([]models.Block, error) {
+	var blocks []models.Block
+	err := s.DB.Where("blocker_id = ?", blockerID).Order("created_at desc").Find(&blocks).Error
+	return blocks, err
+}
+
+// Exclusions implementa BlockSource: quem userID bloqueou e quem bloqueou userID
+func (s *BlockService) Exclusions(userID string) ([]string, error) {
+	var blocks []models.Block
+	err := s.DB.Where("blocker_id = ? OR blocked_id = ?", userID, userID).Find(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			ids = append(ids, b.BlockedID)
+		} else {
+			ids = append(ids, b.BlockerID)
+		}
+	}
+	return ids, nil
+}
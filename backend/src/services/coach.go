@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// CoachRequest é uma mensagem escrita no idioma que o autor está aprendendo
+type CoachRequest struct {
+	Text           string
+	Language       string // idioma praticado (TargetLanguage do autor)
+	NativeLanguage string // idioma das explicações
+	UserID         string
+}
+
+type CorrectionEdit struct {
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Explanation string `json:"explanation"`
+}
+
+// Correction é a revisão do coach: texto corrigido, cada edição explicada no
+// idioma nativo e uma estimativa CEFR do nível da mensagem
+type Correction struct {
+	Corrected string           `json:"corrected"`
+	Edits     []CorrectionEdit `json:"edits"`
+	Level     string           `json:"level,omitempty"`
+	Provider  string           `json:"provider"`
+	Tokens    int              `json:"-"` // cobrados pelo provedor; 0 se desconhecido
+}
+
+// Coach revisa mensagens de quem está praticando
+type Coach interface {
+	Correct(ctx context.Context, req CoachRequest) (*Correction, error)
+}
+
+var ErrNoCoach = errors.New("coach_unavailable")
+
+// NewCoach lê COACH_PROVIDER (gemini, fake ou off). Sem a variável usa o
+// Gemini quando configurado; sem provedor, retorna nil e o modo coach fica indisponível.
+// Se a cadeia de tradução já tem o Gemini, o coach usa esse mesmo provedor e o
+// mesmo breaker; senão ganha um breaker próprio, com a configuração da tradução.
+func NewCoach(translator Translator) Coach {
+	provider := strings.ToLower(os.Getenv("COACH_PROVIDER"))
+	if provider == "" && os.Getenv("GEMINI_API_KEY") != "" {
+		provider = "gemini"
+	}
+
+	switch provider {
+	case "gemini":
+		if coach := chainCoach(translator); coach != nil {
+			log.Println("🎓 Learner coach: gemini (shared with translation)")
+			return coach
+		}
+		g, err := NewGeminiTranslator(os.Getenv("GEMINI_API_KEY"), os.Getenv("GEMINI_MODEL"))
+		if err != nil {
+			log.Printf("⚠️ Coach disabled: %v", err)
+			return nil
+		}
+		log.Println("🎓 Learner coach: gemini")
+		return newResilientTranslator(g)
+	case "fake":
+		return &FakeCoach{}
+	}
+	log.Println("⚠️ No coach provider configured. Learner mode will be disabled.")
+	return nil
+}
+
+// chainCoach procura atrás dos decoradores o provedor resiliente que também
+// revisa mensagens; o cache, o glossário e a cota ficam de fora
+func chainCoach(t Translator) Coach {
+	switch t := t.(type) {
+	case *ResilientTranslator:
+		if _, ok := t.Next.(Coach); ok {
+			return t
+		}
+	case *ChainTranslator:
+		for _, next := range t.Translators {
+			if coach := chainCoach(next); coach != nil {
+				return coach
+			}
+		}
+	case *MeteredTranslator:
+		return chainCoach(t.Next)
+	case *GlossaryTranslator:
+		return chainCoach(t.Next)
+	case *CachedTranslator:
+		return chainCoach(t.Next)
+	}
+	return nil
+}
+
+// Correct pede a revisão em JSON; explicações vêm no idioma nativo do autor
+func (g *GeminiTranslator) Correct(ctx context.Context, req CoachRequest) (*Correction, error) {
+	native := req.NativeLanguage
+	if native == "" {
+		native = "en"
+	}
+	prompt := fmt.Sprintf(`You are a friendly %s tutor. The learner's native language is %s. Correct the message below, keeping its meaning and tone; casual chat style is fine, only fix real mistakes. List every edit with a one-sentence explanation written in %s, and estimate the CEFR level (A1, A2, B1, B2, C1 or C2) the message shows. Respond with JSON {"corrected": "<corrected text>", "edits": [{"original": "<text>", "replacement": "<text>", "explanation": "<text>"}], "level": "<CEFR>"}. Message: %s`, req.Language, native, native, req.Text)
+
+	raw, tokens, err := g.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Correction
+	if err := json.Unmarshal([]byte(raw), &result); err != nil || result.Corrected == "" {
+		return nil, fmt.Errorf("invalid correction response")
+	}
+	result.Level = cefrLevel(result.Level)
+	result.Provider = g.Name()
+	result.Tokens = tokens
+	if result.Edits == nil {
+		result.Edits = []CorrectionEdit{}
+	}
+	return &result, nil
+}
+
+// cefrLevel normaliza a estimativa; valores fora da escala são descartados
+func cefrLevel(level string) string {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if groupLevels[level] {
+		return level
+	}
+	return ""
+}
+
+// FakeCoach troca palavras inteiras de Fixes, gerando uma edição por troca, e
+// estima o nível pelo tamanho da mensagem
+type FakeCoach struct {
+	Fixes map[string]string
+}
+
+func (f *FakeCoach) Correct(ctx context.Context, req CoachRequest) (*Correction, error) {
+	words := strings.Fields(req.Text)
+	edits := []CorrectionEdit{}
+	for i, w := range words {
+		if fix, ok := f.Fixes[w]; ok {
+			edits = append(edits, CorrectionEdit{
+				Original:    w,
+				Replacement: fix,
+				Explanation: fmt.Sprintf("[%s] %s -> %s", req.NativeLanguage, w, fix),
+			})
+			words[i] = fix
+		}
+	}
+
+	level := "B2"
+	switch {
+	case len(words) < 6:
+		level = "A2"
+	case len(words) < 15:
+		level = "B1"
+	}
+	return &Correction{Corrected: strings.Join(words, " "), Edits: edits, Level: level, Provider: "fake"}, nil
+}
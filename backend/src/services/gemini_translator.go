@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const defaultGeminiModel = "gemini-1.5-flash"
+
+// GeminiTranslator traduz via Gemini e detecta o idioma de origem na mesma chamada
+type GeminiTranslator struct {
+	client *genai.Client
+	Model  string
+}
+
+func NewGeminiTranslator(apiKey, model string) (*GeminiTranslator, error) {
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	if model == "" {
+		model = defaultGeminiModel
+	}
+	return &GeminiTranslator{client: client, Model: model}, nil
+}
+
+func (g *GeminiTranslator) Name() string { return "gemini" }
+
+func (g *GeminiTranslator) ModelVersion() string { return "gemini/" + g.Model }
+
+// Translate usa req.Source apenas como dica: quem está praticando muitas vezes
+// escreve no idioma alvo, então o idioma real vem da detecção do modelo
+func (g *GeminiTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	prompt := fmt.Sprintf(`Detect the language of the text below and translate it to %s. %s If the text is already in %s, return it unchanged. %sRespond with JSON {"source_language": "<BCP-47 code>", "translation": "<translated text>"}. Text: %s`, req.Target, sourcePrompt(req), req.Target, contextPrompt(req), req.Text)
+
+	raw, tokens, err := g.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		SourceLanguage string `json:"source_language"`
+		Translation    string `json:"translation"`
+	}
+	if err := json.Unmarshal([]byte(raw), &result); err != nil || result.Translation == "" {
+		return nil, fmt.Errorf("invalid translation response")
+	}
+	return &Translation{Text: result.Translation, SourceLanguage: result.SourceLanguage, Provider: g.Name(), Tokens: tokens}, nil
+}
+
+// TranslateStream pede só o texto traduzido (sem JSON) para poder repassar os
+// trechos conforme chegam; o idioma de origem fica sendo o detectado ou a dica
+func (g *GeminiTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	prompt := fmt.Sprintf("Translate the following text to %s. %s If the text is already in %s, return it unchanged. %sReturn ONLY the translated text without any explanations or quotes: %s", req.Target, sourcePrompt(req), req.Target, contextPrompt(req), req.Text)
+
+	model := g.client.GenerativeModel(g.Model)
+	model.SetTemperature(0.2)
+	iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+
+	var translated strings.Builder
+	tokens := 0
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Cada pedaço traz o total acumulado; o último vale
+		if t := usageTokens(resp.UsageMetadata); t > 0 {
+			tokens = t
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			continue
+		}
+		for _, part := range resp.Candidates[0].Content.Parts {
+			delta := fmt.Sprintf("%v", part)
+			translated.WriteString(delta)
+			onDelta(delta)
+		}
+	}
+
+	if translated.Len() == 0 {
+		return nil, fmt.Errorf("no translation generated")
+	}
+	source := req.Detected
+	if source == "" {
+		source = req.Source
+	}
+	return &Translation{Text: translated.String(), SourceLanguage: source, Provider: g.Name(), Tokens: tokens}, nil
+}
+
+// contextPrompt lista as mensagens anteriores da sala como referência
+func contextPrompt(req TranslationRequest) string {
+	if len(req.Context) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Earlier messages in this conversation, for reference only (keep names, pronouns and gender agreement consistent with them; do not translate them):\n")
+	for _, m := range req.Context {
+		fmt.Fprintf(&b, "%s: %s\n", m.Speaker, m.Text)
+	}
+	return b.String()
+}
+
+// sourcePrompt descreve o idioma de origem: o detectado, se houver, ou a dica
+func sourcePrompt(req TranslationRequest) string {
+	if req.Detected != "" {
+		return fmt.Sprintf("The text is written in %s.", req.Detected)
+	}
+	hint := req.Source
+	if hint == "" {
+		hint = "auto"
+	}
+	return fmt.Sprintf("The author's native language is %s, but they may be writing in another language.", hint)
+}
+
+// generate devolve a resposta e os tokens cobrados (prompt + resposta)
+func (g *GeminiTranslator) generate(ctx context.Context, prompt string) (string, int, error) {
+	model := g.client.GenerativeModel(g.Model)
+	model.SetTemperature(0.2) // Low temperature for accuracy
+	model.ResponseMIMEType = "application/json"
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", 0, fmt.Errorf("no translation generated")
+	}
+
+	translated := ""
+	for _, part := range resp.Candidates[0].Content.Parts {
+		translated += fmt.Sprintf("%v", part)
+	}
+
+	return translated, usageTokens(resp.UsageMetadata), nil
+}
+
+func usageTokens(usage *genai.UsageMetadata) int {
+	if usage == nil {
+		return 0
+	}
+	return int(usage.TotalTokenCount)
+}
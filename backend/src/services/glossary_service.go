@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/gorm"
+)
+
+const (
+	maxGlossaryEntries = 200
+	maxGlossaryTermLen = 100
+)
+
+var (
+	ErrGlossaryTermRequired  = errors.New("term_required")
+	ErrGlossaryTermTooLong   = errors.New("term_too_long")
+	ErrGlossaryPairRequired  = errors.New("language_pair_required")
+	ErrGlossaryEntryExists   = errors.New("glossary_entry_exists")
+	ErrGlossaryEntryNotFound = errors.New("glossary_entry_not_found")
+	ErrGlossaryFull          = errors.New("glossary_full")
+)
+
+// GlossaryInput é o corpo aceito por POST/PUT /v1/glossary
+type GlossaryInput struct {
+	Term           string `json:"term"`
+	Translation    string `json:"translation"`
+	SourceLanguage string `json:"source_lang"`
+	TargetLanguage string `json:"target_lang"`
+}
+
+// GlossaryService guarda os glossários dos usuários no Postgres e monta o
+// glossário combinado de uma sala
+type GlossaryService struct {
+	DB *gorm.DB
+}
+
+func (s *GlossaryService) List(userID string) ([]models.GlossaryEntry, error) {
+	var entries []models.GlossaryEntry
+	err := s.DB.Where("user_id = ?", userID).Order("term").Find(&entries).Error
+	return entries, err
+}
+
+func (s *GlossaryService) Create(userID string, input GlossaryInput) (*models.GlossaryEntry, error) {
+	input, err := normalizeGlossaryInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.GlossaryEntry{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxGlossaryEntries {
+		return nil, ErrGlossaryFull
+	}
+	if err := s.checkDuplicate(userID, "", input); err != nil {
+		return nil, err
+	}
+
+	entry := models.GlossaryEntry{
+		UserID:         userID,
+		Term:           input.Term,
+		Translation:    input.Translation,
+		SourceLanguage: input.SourceLanguage,
+		TargetLanguage: input.TargetLanguage,
+	}
+	if err := s.DB.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *GlossaryService) Update(userID, id string, input GlossaryInput) (*models.GlossaryEntry, error) {
+	input, err := normalizeGlossaryInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.GlossaryEntry
+	err = s.DB.First(&entry, "id = ? AND user_id = ?", id, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGlossaryEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicate(userID, id, input); err != nil {
+		return nil, err
+	}
+
+	entry.Term = input.Term
+	entry.Translation = input.Translation
+	entry.SourceLanguage = input.SourceLanguage
+	entry.TargetLanguage = input.TargetLanguage
+	if err := s.DB.Save(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *GlossaryService) Delete(userID, id string) (bool, error) {
+	result := s.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.GlossaryEntry{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RoomGlossary junta os glossários dos membros da sala. Em caso de conflito
+// (mesmo termo e par de idiomas) vale a entrada do primeiro usuário da lista,
+// então quem chama passa o autor da mensagem primeiro.
+func (s *GlossaryService) RoomGlossary(userIDs ...string) ([]GlossaryTerm, error) {
+	byUser, err := s.UserGlossaries(userIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return MergeGlossaries(byUser, userIDs...), nil
+}
+
+// UserGlossaries carrega os termos de cada usuário numa consulta só, com as
+// buscas já compiladas, para a sala guardar enquanto os membros não mudam
+func (s *GlossaryService) UserGlossaries(userIDs ...string) (map[string][]GlossaryTerm, error) {
+	byUser := make(map[string][]GlossaryTerm, len(userIDs))
+	if len(userIDs) == 0 {
+		return byUser, nil
+	}
+	var entries []models.GlossaryEntry
+	if err := s.DB.Where("user_id IN ?", userIDs).Order("created_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		byUser[e.UserID] = append(byUser[e.UserID], GlossaryTerm{
+			Term:        e.Term,
+			Translation: e.Translation,
+			Source:      e.SourceLanguage,
+			Target:      e.TargetLanguage,
+		}.compiled())
+	}
+	return byUser, nil
+}
+
+// MergeGlossaries junta os glossários na ordem dos usuários; em conflito vale o primeiro
+func MergeGlossaries(byUser map[string][]GlossaryTerm, userIDs ...string) []GlossaryTerm {
+	var terms []GlossaryTerm
+	seen := make(map[string]bool)
+	for _, id := range userIDs {
+		for _, t := range byUser[id] {
+			key := strings.ToLower(t.Term) + "\x00" + t.Source + "\x00" + t.Target
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func (s *GlossaryService) checkDuplicate(userID, exceptID string, input GlossaryInput) error {
+	var count int64
+	err := s.DB.Model(&models.GlossaryEntry{}).
+		Where("user_id = ? AND LOWER(term) = LOWER(?) AND source_language = ? AND target_language = ? AND id <> ?",
+			userID, input.Term, input.SourceLanguage, input.TargetLanguage, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrGlossaryEntryExists
+	}
+	return nil
+}
+
+// normalizeGlossaryInput valida a entrada; mapeamentos forçados exigem o par
+// de idiomas, termos "não traduzir" valem para qualquer par se omitido
+func normalizeGlossaryInput(input GlossaryInput) (GlossaryInput, error) {
+	input.Term = strings.TrimSpace(input.Term)
+	input.Translation = strings.TrimSpace(input.Translation)
+	input.SourceLanguage = strings.ToLower(strings.TrimSpace(input.SourceLanguage))
+	input.TargetLanguage = strings.ToLower(strings.TrimSpace(input.TargetLanguage))
+
+	switch {
+	case input.Term == "":
+		return input, ErrGlossaryTermRequired
+	case len(input.Term) > maxGlossaryTermLen || len(input.Translation) > maxGlossaryTermLen:
+		return input, ErrGlossaryTermTooLong
+	case input.Translation != "" && (input.SourceLanguage == "" || input.TargetLanguage == ""):
+		return input, ErrGlossaryPairRequired
+	}
+	return input, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultGroupCapacity = 6
+	minGroupCapacity     = 3
+	maxGroupCapacity     = 6
+	// Uma mesa continua viva enquanto tiver pelo menos esse número de membros
+	minGroupMembers = 2
+	groupTTL        = 6 * time.Hour
+	groupLevelAny   = "any"
+)
+
+var (
+	ErrInvalidGroupLevel = errors.New("invalid_level")
+	ErrGroupLanguage     = errors.New("target_lang_required")
+)
+
+var groupLevels = map[string]bool{"A1": true, "A2": true, "B1": true, "B2": true, "C1": true, "C2": true}
+
+// Layout no Redis:
+//
+//	group:open:<lang>:<level>  ZSET  mesas com vaga, score = número de membros
+//	group:members:<roomID>     HASH  userID -> GroupRequest em JSON
+//	group:room:<roomID>        HASH  queue (fila de origem), active ("1" depois do 2º membro)
+//	group:user:<userID>        STRING mesa atual do usuário
+type GroupService struct {
+	Redis    *redis.Client
+	Capacity int
+}
+
+// GroupRequest é o pedido join_group: mesas são separadas por idioma praticado e nível
+type GroupRequest struct {
+	UserID         string `json:"user_id"`
+	NativeLanguage string `json:"native_lang"`
+	TargetLanguage string `json:"target_lang"`
+	Level          string `json:"level,omitempty"`
+}
+
+type GroupRoom struct {
+	ID       string         `json:"room_id"`
+	Capacity int            `json:"capacity"`
+	Members  []GroupRequest `json:"members"`
+
+	// Closed indica que a mesa ficou abaixo do mínimo; Evicted são os membros que sobraram
+	Closed  bool     `json:"-"`
+	Evicted []string `json:"-"`
+}
+
+// Active informa se a mesa já tem gente suficiente para conversar
+func (g *GroupRoom) Active() bool { return len(g.Members) >= minGroupMembers }
+
+func groupQueueKey(lang, level string) string { return "group:open:" + lang + ":" + level }
+func groupMembersKey(roomID string) string    { return "group:members:" + roomID }
+func groupUserKey(userID string) string       { return "group:user:" + userID }
+
+// groupJoinScript coloca o usuário na mesa mais cheia que ainda tem vaga (para
+// completar grupos) ou abre uma nova.
+//
+//	KEYS[1] fila de mesas abertas, KEYS[2] mesa atual do usuário
+//	ARGV[1] userID, ARGV[2] pedido JSON, ARGV[3] capacidade, ARGV[4] ID da nova mesa, ARGV[5] TTL
+var groupJoinScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[2])
+if current then
+	return current
+end
+
+local open = redis.call('ZREVRANGEBYSCORE', KEYS[1], '(' .. ARGV[3], '-inf', 'LIMIT', 0, 1)
+local room = open[1] or ARGV[4]
+local members = 'group:members:' .. room
+local meta = 'group:room:' .. room
+
+redis.call('HSET', members, ARGV[1], ARGV[2])
+local count = redis.call('HLEN', members)
+if count >= tonumber(ARGV[3]) then
+	redis.call('ZREM', KEYS[1], room)
+else
+	redis.call('ZADD', KEYS[1], count, room)
+end
+redis.call('HSET', meta, 'queue', KEYS[1])
+if count >= 2 then
+	redis.call('HSET', meta, 'active', '1')
+end
+redis.call('SET', KEYS[2], room, 'EX', ARGV[5])
+redis.call('EXPIRE', members, ARGV[5])
+redis.call('EXPIRE', meta, ARGV[5])
+return room
+`)
+
+// groupLeaveScript tira o usuário da mesa. Uma mesa ativa que cai abaixo de dois
+// membros é encerrada e quem sobrou é removido junto.
+//
+//	KEYS[1] mesa atual do usuário; ARGV[1] userID
+//	Retorna {} se o usuário não estava em mesa, {room, 'left'} ou {room, 'closed', restantes...}
+var groupLeaveScript = redis.NewScript(`
+local room = redis.call('GET', KEYS[1])
+if not room then
+	return {}
+end
+redis.call('DEL', KEYS[1])
+
+local members = 'group:members:' .. room
+local meta = 'group:room:' .. room
+redis.call('HDEL', members, ARGV[1])
+local queue = redis.call('HGET', meta, 'queue')
+local count = redis.call('HLEN', members)
+
+if count == 0 or (count < 2 and redis.call('HGET', meta, 'active') == '1') then
+	local rest = redis.call('HKEYS', members)
+	local result = {room, 'closed'}
+	for _, id in ipairs(rest) do
+		redis.call('DEL', 'group:user:' .. id)
+		table.insert(result, id)
+	end
+	redis.call('DEL', members, meta)
+	if queue then
+		redis.call('ZREM', queue, room)
+	end
+	return result
+end
+
+if queue then
+	redis.call('ZADD', queue, count, room)
+end
+return {room, 'left'}
+`)
+
+// Join coloca o usuário numa mesa do seu idioma/nível; quem já está numa mesa
+// recebe a mesa atual
+func (s *GroupService) Join(ctx context.Context, req GroupRequest) (*GroupRoom, error) {
+	req.TargetLanguage = strings.TrimSpace(req.TargetLanguage)
+	if req.TargetLanguage == "" {
+		return nil, ErrGroupLanguage
+	}
+	level, err := normalizeGroupLevel(req.Level)
+	if err != nil {
+		return nil, err
+	}
+	req.Level = level
+
+	val, _ := json.Marshal(req)
+	roomID, err := groupJoinScript.Run(ctx, s.Redis,
+		[]string{groupQueueKey(req.TargetLanguage, level), groupUserKey(req.UserID)},
+		req.UserID, val, s.capacity(), "group_"+uuid.New().String(), int(groupTTL.Seconds()),
+	).Text()
+	if err != nil {
+		return nil, err
+	}
+	return s.Room(ctx, roomID)
+}
+
+// Leave tira o usuário da mesa atual; retorna nil se ele não estava em nenhuma
+func (s *GroupService) Leave(ctx context.Context, userID string) (*GroupRoom, error) {
+	res, err := groupLeaveScript.Run(ctx, s.Redis, []string{groupUserKey(userID)}, userID).StringSlice()
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+	if res[1] == "closed" {
+		return &GroupRoom{ID: res[0], Capacity: s.capacity(), Closed: true, Evicted: res[2:]}, nil
+	}
+	return s.Room(ctx, res[0])
+}
+
+// Room lê os membros atuais da mesa (ordenados por ID)
+func (s *GroupService) Room(ctx context.Context, roomID string) (*GroupRoom, error) {
+	entries, err := s.Redis.HGetAll(ctx, groupMembersKey(roomID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	room := &GroupRoom{ID: roomID, Capacity: s.capacity(), Members: []GroupRequest{}}
+	for _, data := range entries {
+		var member GroupRequest
+		if json.Unmarshal([]byte(data), &member) == nil {
+			room.Members = append(room.Members, member)
+		}
+	}
+	sort.Slice(room.Members, func(i, j int) bool { return room.Members[i].UserID < room.Members[j].UserID })
+	return room, nil
+}
+
+func (s *GroupService) capacity() int {
+	switch {
+	case s.Capacity <= 0:
+		return defaultGroupCapacity
+	case s.Capacity < minGroupCapacity:
+		return minGroupCapacity
+	case s.Capacity > maxGroupCapacity:
+		return maxGroupCapacity
+	}
+	return s.Capacity
+}
+
+func normalizeGroupLevel(level string) (string, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level == "" || level == "ANY" {
+		return groupLevelAny, nil
+	}
+	if !groupLevels[level] {
+		return "", ErrInvalidGroupLevel
+	}
+	return level, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPTranslator fala a API do LibreTranslate (POST /translate), também
+// implementada por outros backends de tradução automática self-hosted
+type HTTPTranslator struct {
+	URL        string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func NewHTTPTranslator(url, apiKey string) *HTTPTranslator {
+	return &HTTPTranslator{
+		URL:        strings.TrimSuffix(url, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *HTTPTranslator) Name() string { return "libretranslate" }
+
+func (t *HTTPTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	// A origem declarada é só uma dica; sem detecção prévia o backend detecta
+	source := "auto"
+	if req.Detected != "" {
+		source = baseLanguage(req.Detected)
+	}
+	body, _ := json.Marshal(map[string]string{
+		"q":       req.Text,
+		"source":  source,
+		"target":  baseLanguage(req.Target),
+		"format":  "text",
+		"api_key": t.APIKey,
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ProviderError{Provider: t.Name(), StatusCode: resp.StatusCode}
+	}
+
+	var result struct {
+		TranslatedText   string `json:"translatedText"`
+		DetectedLanguage *struct {
+			Language   string  `json:"language"`
+			Confidence float64 `json:"confidence"`
+		} `json:"detectedLanguage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	translation := &Translation{Text: result.TranslatedText, Provider: t.Name()}
+	if result.DetectedLanguage != nil {
+		translation.SourceLanguage = result.DetectedLanguage.Language
+	}
+	return translation, nil
+}
+
+// ProviderError é uma resposta HTTP de erro de um provedor de tradução
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s request failed: %d %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Retryable: limite de taxa e falhas do servidor costumam ser passageiros
+func (e *ProviderError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// baseLanguage reduz uma tag BCP-47 ao idioma (pt-BR -> pt), formato aceito pelo LibreTranslate
+func baseLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
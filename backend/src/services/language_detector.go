@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Detection é o idioma identificado num texto, como tag BCP-47 ("und" quando
+// não há como dizer), com confiança entre 0 e 1
+type Detection struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// LanguageDetector identifica o idioma de uma mensagem antes da tradução
+type LanguageDetector interface {
+	Detect(ctx context.Context, text string) (Detection, error)
+}
+
+const undetermined = "und"
+
+// MinDetectionConfidence é a confiança a partir da qual a detecção substitui o
+// idioma declarado pelo autor
+const MinDetectionConfidence = 0.5
+
+// NewLanguageDetector lê LANGUAGE_DETECTOR: local (padrão, n-gramas, funciona
+// offline), libretranslate (POST /detect, caindo para o local em erro) ou off
+func NewLanguageDetector() LanguageDetector {
+	local := NewNgramDetector()
+	switch strings.ToLower(os.Getenv("LANGUAGE_DETECTOR")) {
+	case "off":
+		return nil
+	case "libretranslate":
+		url := os.Getenv("LIBRETRANSLATE_URL")
+		if url == "" {
+			log.Println("⚠️ LIBRETRANSLATE_URL not found. Using local language detection.")
+			return local
+		}
+		return &fallbackDetector{primary: NewHTTPTranslator(url, os.Getenv("LIBRETRANSLATE_API_KEY")), fallback: local}
+	default:
+		return local
+	}
+}
+
+// SameLanguage compara só o idioma das tags (pt-BR e pt são o mesmo)
+func SameLanguage(a, b string) bool {
+	return a != "" && baseLanguage(a) == baseLanguage(b)
+}
+
+type fallbackDetector struct {
+	primary, fallback LanguageDetector
+}
+
+func (d *fallbackDetector) Detect(ctx context.Context, text string) (Detection, error) {
+	detection, err := d.primary.Detect(ctx, text)
+	if err == nil {
+		return detection, nil
+	}
+	log.Printf("⚠️ Language detection failed, using local: %v", err)
+	return d.fallback.Detect(ctx, text)
+}
+
+// Detect usa o POST /detect do LibreTranslate, que devolve a confiança de 0 a 100
+func (t *HTTPTranslator) Detect(ctx context.Context, text string) (Detection, error) {
+	body, _ := json.Marshal(map[string]string{"q": text, "api_key": t.APIKey})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL+"/detect", bytes.NewReader(body))
+	if err != nil {
+		return Detection{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return Detection{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Detection{}, &ProviderError{Provider: t.Name(), StatusCode: resp.StatusCode}
+	}
+
+	var results []struct {
+		Language   string  `json:"language"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return Detection{}, err
+	}
+	if len(results) == 0 {
+		return Detection{Language: undetermined}, nil
+	}
+	return Detection{Language: results[0].Language, Confidence: results[0].Confidence / 100}, nil
+}
+
+// NgramDetector compara palavras e n-gramas de letras da mensagem com perfis
+// montados a partir de textos de amostra. Escritas não latinas são resolvidas
+// pelo bloco Unicode, sem perfil; as que servem a vários idiomas (cirílico,
+// árabe, Han, devanágari) só dão um palpite, abaixo de MinDetectionConfidence.
+type NgramDetector struct {
+	profiles map[string]map[string]float64
+}
+
+// Textos de amostra no registro de chat, que é o que os perfis precisam reconhecer
+var ngramSamples = map[string]string{
+	"en": `hi how are you doing today I am fine thanks and you what do you do for work I would like to practice my english with someone
+		where are you from I live in the city near the beach it is very hot this week do you like music movies or sports
+		my favorite thing is to travel with my friends and family what was the last book you read that sounds great
+		sorry I did not understand can you say that again please nice to meet you have a good night see you tomorrow`,
+	"pt": `oi tudo bem com você eu estou bem obrigado e você o que você faz no trabalho eu gostaria de praticar meu português com alguém
+		de onde você é eu moro na cidade perto da praia está muito quente esta semana você gosta de música filmes ou esportes
+		minha coisa favorita é viajar com meus amigos e minha família qual foi o último livro que você leu que legal
+		desculpa não entendi você pode repetir por favor prazer em te conhecer tenha uma boa noite até amanhã não são então`,
+	"es": `hola cómo estás yo estoy bien gracias y tú a qué te dedicas en el trabajo me gustaría practicar mi español con alguien
+		de dónde eres yo vivo en la ciudad cerca de la playa hace mucho calor esta semana te gusta la música las películas o los deportes
+		lo que más me gusta es viajar con mis amigos y mi familia cuál fue el último libro que leíste qué bueno
+		perdón no entendí puedes repetirlo por favor mucho gusto en conocerte que tengas buena noche hasta mañana pero muy`,
+	"fr": `salut comment ça va je vais bien merci et toi qu'est-ce que tu fais dans la vie j'aimerais pratiquer mon français avec quelqu'un
+		tu viens d'où j'habite dans la ville près de la plage il fait très chaud cette semaine tu aimes la musique les films ou le sport
+		ce que je préfère c'est voyager avec mes amis et ma famille quel est le dernier livre que tu as lu c'est super
+		désolé je n'ai pas compris tu peux répéter s'il te plaît enchanté de te rencontrer bonne nuit à demain mais aussi`,
+	"de": `hallo wie geht es dir mir geht es gut danke und dir was machst du beruflich ich möchte mein deutsch mit jemandem üben
+		woher kommst du ich wohne in der stadt in der nähe vom strand es ist sehr heiß diese woche magst du musik filme oder sport
+		am liebsten reise ich mit meinen freunden und meiner familie was war das letzte buch das du gelesen hast das klingt toll
+		entschuldigung ich habe das nicht verstanden kannst du das bitte wiederholen schön dich kennenzulernen gute nacht bis morgen`,
+	"it": `ciao come stai io sto bene grazie e tu che lavoro fai mi piacerebbe praticare il mio italiano con qualcuno
+		di dove sei io vivo in città vicino alla spiaggia fa molto caldo questa settimana ti piace la musica i film o lo sport
+		la cosa che preferisco è viaggiare con i miei amici e la mia famiglia qual è l'ultimo libro che hai letto che bello
+		scusa non ho capito puoi ripetere per favore piacere di conoscerti buona notte a domani però anche molto`,
+	"nl": `hoi hoe gaat het met je met mij gaat het goed dank je en met jou wat voor werk doe je ik wil graag mijn nederlands oefenen met iemand
+		waar kom je vandaan ik woon in de stad vlak bij het strand het is erg warm deze week hou je van muziek films of sport
+		het liefst reis ik met mijn vrienden en mijn familie wat was het laatste boek dat je hebt gelezen dat klinkt goed
+		sorry ik heb het niet begrepen kun je dat herhalen alsjeblieft leuk je te ontmoeten welterusten tot morgen`,
+}
+
+// Idioma de cada escrita (kana é checado antes do Han); shared marca as
+// escritas de vários idiomas, em que o idioma mais comum é só um palpite
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+	shared   bool
+}{
+	{unicode.Hiragana, "ja", false},
+	{unicode.Katakana, "ja", false},
+	{unicode.Hangul, "ko", false},
+	{unicode.Han, "zh", true},
+	{unicode.Cyrillic, "ru", true},
+	{unicode.Arabic, "ar", true},
+	{unicode.Devanagari, "hi", true},
+	{unicode.Greek, "el", false},
+	{unicode.Hebrew, "he", false},
+	{unicode.Thai, "th", false},
+}
+
+// Confiança do palpite numa escrita compartilhada: fica a dica do autor
+const sharedScriptConfidence = 0.3
+
+// Abaixo disso a confiança é reduzida proporcionalmente: mensagens curtas
+// como "ok" ou "haha" não dizem muito
+const ngramFullConfidenceLetters = 20
+
+const ngramWordWeight = 3
+
+// Perfis latinos: abaixo de ngramMinSimilarity o texto provavelmente é de um
+// idioma sem perfil (sueco cai perto do alemão) e a confiança cai junto; se o
+// segundo colocado chega a menos de ngramMinMargin do primeiro, não há detecção
+const (
+	ngramMinSimilarity = 0.25
+	ngramMinMargin     = 0.25
+)
+
+func NewNgramDetector() *NgramDetector {
+	d := &NgramDetector{profiles: make(map[string]map[string]float64, len(ngramSamples))}
+	for language, sample := range ngramSamples {
+		d.profiles[language] = ngramVector(sample)
+	}
+	return d
+}
+
+func (d *NgramDetector) Detect(ctx context.Context, text string) (Detection, error) {
+	letters, scripts := 0, make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				scripts[s.language]++
+				break
+			}
+		}
+	}
+	if letters < 2 {
+		return Detection{Language: undetermined}, nil
+	}
+
+	// Japonês mistura kana e kanji; qualquer kana decide
+	if scripts["ja"] > 0 && scripts["ja"]+scripts["zh"] > letters/2 {
+		return Detection{Language: "ja", Confidence: float64(scripts["ja"]+scripts["zh"]) / float64(letters)}, nil
+	}
+	for _, s := range scriptLanguages {
+		if n := scripts[s.language]; n > letters/2 {
+			confidence := float64(n) / float64(letters)
+			if s.shared {
+				confidence = math.Min(confidence, sharedScriptConfidence)
+			}
+			return Detection{Language: s.language, Confidence: confidence}, nil
+		}
+	}
+
+	vector := ngramVector(text)
+	type score struct {
+		language string
+		value    float64
+	}
+	scores := make([]score, 0, len(d.profiles))
+	for language, profile := range d.profiles {
+		scores = append(scores, score{language, cosine(vector, profile)})
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].value > scores[j].value })
+
+	best := scores[0]
+	if best.value == 0 {
+		return Detection{Language: undetermined}, nil
+	}
+	// Confiança = margem relativa sobre o segundo colocado, penalizada em textos
+	// curtos e em textos que não se parecem muito com nenhum perfil
+	margin := 1 - scores[1].value/best.value
+	if margin < ngramMinMargin {
+		return Detection{Language: undetermined}, nil
+	}
+	confidence := math.Min(1, margin*2)
+	if letters < ngramFullConfidenceLetters {
+		confidence *= float64(letters) / ngramFullConfidenceLetters
+	}
+	if best.value < ngramMinSimilarity {
+		confidence *= best.value / ngramMinSimilarity
+	}
+	return Detection{Language: best.language, Confidence: math.Round(confidence*100) / 100}, nil
+}
+
+// ngramVector conta bigramas e trigramas de cada palavra (com bordas) e a
+// própria palavra, que pesa mais por distinguir línguas próximas, e normaliza o vetor
+func ngramVector(text string) map[string]float64 {
+	counts := make(map[string]float64)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		counts[" "+word+" "] += ngramWordWeight
+		runes := []rune(" " + word + " ")
+		for n := 2; n <= 3; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				counts[string(runes[i:i+n])]++
+			}
+		}
+	}
+
+	var norm float64
+	for _, c := range counts {
+		norm += c * c
+	}
+	norm = math.Sqrt(norm)
+	for gram := range counts {
+		counts[gram] /= norm
+	}
+	return counts
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for gram, v := range a {
+		dot += v * b[gram]
+	}
+	return dot
+}
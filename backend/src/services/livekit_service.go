@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultLiveKitTokenTTL = 6 * time.Hour
+	liveKitEmptyTimeout    = 5 * 60
+)
+
+// LiveKitService emite access tokens e provisiona salas via Twirp (RoomService),
+// falando o protocolo diretamente. O server-sdk-go puxa pion/webrtc, NATS e o
+// livekit/protocol inteiros só para assinar um JWT e fazer dois POSTs, então
+// ficamos com golang-jwt e net/http (e o go.mod sem essas dependências). Os
+// claims do auth.AccessToken e o corpo do Twirp estão fixados em tests/livekit_test.go.
+type LiveKitService struct {
+	URL        string
+	APIKey     string
+	APISecret  string
+	TokenTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+// VideoGrant segue o formato de claims "video" do LiveKit
+type VideoGrant struct {
+	RoomCreate     bool   `json:"roomCreate,omitempty"`
+	RoomList       bool   `json:"roomList,omitempty"`
+	RoomAdmin      bool   `json:"roomAdmin,omitempty"`
+	RoomRecord     bool   `json:"roomRecord,omitempty"`
+	RoomJoin       bool   `json:"roomJoin,omitempty"`
+	Room           string `json:"room,omitempty"`
+	CanPublish     *bool  `json:"canPublish,omitempty"`
+	CanSubscribe   *bool  `json:"canSubscribe,omitempty"`
+	CanPublishData *bool  `json:"canPublishData,omitempty"`
+}
+
+type LiveKitClaims struct {
+	Name  string      `json:"name,omitempty"`
+	Video *VideoGrant `json:"video,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func NewLiveKitService() *LiveKitService {
+	url := os.Getenv("LIVEKIT_URL")
+	key := os.Getenv("LIVEKIT_API_KEY")
+	secret := os.Getenv("LIVEKIT_API_SECRET")
+	if url == "" || key == "" || secret == "" {
+		log.Println("⚠️ LIVEKIT_URL/LIVEKIT_API_KEY/LIVEKIT_API_SECRET not found. LiveKit will be disabled.")
+		return nil
+	}
+
+	return &LiveKitService{
+		URL:        url,
+		APIKey:     key,
+		APISecret:  secret,
+		TokenTTL:   defaultLiveKitTokenTTL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ParticipantToken gera um token que só permite entrar na sala indicada
+func (s *LiveKitService) ParticipantToken(identity, name, room string) (string, error) {
+	allow := true
+	return s.sign(identity, name, &VideoGrant{
+		RoomJoin:       true,
+		Room:           room,
+		CanPublish:     &allow,
+		CanSubscribe:   &allow,
+		CanPublishData: &allow,
+	})
+}
+
+// CreateRoom provisiona a sala no servidor LiveKit (idempotente no lado do SFU)
+func (s *LiveKitService) CreateRoom(ctx context.Context, room string, maxParticipants int) error {
+	token, err := s.sign("nexus-core", "", &VideoGrant{RoomCreate: true})
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":             room,
+		"empty_timeout":    liveKitEmptyTimeout,
+		"max_participants": maxParticipants,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.httpURL()+"/twirp/livekit.RoomService/CreateRoom", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("livekit create room failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *LiveKitService) sign(identity, name string, grant *VideoGrant) (string, error) {
+	ttl := s.TokenTTL
+	if ttl == 0 {
+		ttl = defaultLiveKitTokenTTL
+	}
+	now := time.Now()
+	claims := &LiveKitClaims{
+		Name:  name,
+		Video: grant,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.APIKey,
+			Subject:   identity,
+			ID:        identity,
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.APISecret))
+}
+
+// httpURL converte o endereço wss:// usado pelos clientes no endpoint HTTP da API
+func (s *LiveKitService) httpURL() string {
+	url := strings.TrimSuffix(s.URL, "/")
+	if strings.HasPrefix(url, "wss://") {
+		return "https://" + strings.TrimPrefix(url, "wss://")
+	}
+	if strings.HasPrefix(url, "ws://") {
+		return "http://" + strings.TrimPrefix(url, "ws://")
+	}
+	return url
+}
+
+var (
+	ErrWebhookUnsigned = errors.New("webhook_unsigned")
+	ErrWebhookInvalid  = errors.New("webhook_invalid_signature")
+)
+
+// WebhookEvent é o subconjunto do payload de webhook do LiveKit que usamos
+type WebhookEvent struct {
+	ID          string              `json:"id"`
+	Event       string              `json:"event"`
+	CreatedAt   string              `json:"createdAt"`
+	Room        *WebhookRoom        `json:"room,omitempty"`
+	Participant *WebhookParticipant `json:"participant,omitempty"`
+}
+
+type WebhookRoom struct {
+	SID  string `json:"sid"`
+	Name string `json:"name"`
+}
+
+type WebhookParticipant struct {
+	SID      string `json:"sid"`
+	Identity string `json:"identity"`
+	Name     string `json:"name"`
+}
+
+type webhookClaims struct {
+	SHA256 string `json:"sha256"`
+	jwt.RegisteredClaims
+}
+
+// Time retorna o instante do evento (createdAt vem como unix em string no protojson)
+func (e *WebhookEvent) Time() time.Time {
+	if sec, err := strconv.ParseInt(e.CreatedAt, 10, 64); err == nil && sec > 0 {
+		return time.Unix(sec, 0)
+	}
+	return time.Now()
+}
+
+// VerifyWebhook valida o JWT do header Authorization (assinado com o API secret,
+// com o sha256 do corpo no claim "sha256") e decodifica o evento. São as mesmas
+// checagens do webhook.ReceiveWebhookEvent do SDK, que não usamos (ver LiveKitService).
+func (s *LiveKitService) VerifyWebhook(body []byte, authHeader string) (*WebhookEvent, error) {
+	tokenString := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	if tokenString == "" {
+		return nil, ErrWebhookUnsigned
+	}
+
+	claims := &webhookClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.APISecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(s.APIKey))
+	if err != nil || !token.Valid {
+		return nil, ErrWebhookInvalid
+	}
+
+	sum := sha256.Sum256(body)
+	expected := base64.StdEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(claims.SHA256), []byte(expected)) != 1 {
+		return nil, ErrWebhookInvalid
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recentKey identifica a dupla independente da ordem (mesmo formato usado no matchScript)
+func recentKey(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return "match:recent:" + a + ":" + b
+}
+
+// markRecent coloca todas as duplas entre userIDs em cooldown; a chave expira
+// sozinha, então o estado sobrevive a reinícios e vale para todas as instâncias
+func (s *MatchService) markRecent(ctx context.Context, pipe redis.Pipeliner, userIDs ...string) {
+	if s.RecentCooldown <= 0 {
+		return
+	}
+	for i, a := range userIDs {
+		for _, b := range userIDs[i+1:] {
+			if a != b {
+				pipe.Set(ctx, recentKey(a, b), 1, s.RecentCooldown)
+			}
+		}
+	}
+}
+
+// RecentlyPaired diz se a dupla conversou há menos de RecentCooldown
+func (s *MatchService) RecentlyPaired(a, b string) (bool, error) {
+	n, err := s.Redis.Exists(context.Background(), recentKey(a, b)).Result()
+	return n > 0, err
+}
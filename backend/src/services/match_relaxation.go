@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Níveis de relaxamento da fila
+const (
+	RelaxExact  = 0 // par exato: nativo <-> alvo
+	RelaxSpoken = 1 // parceiro nativo no meu alvo que queira qualquer idioma que eu falo
+	RelaxOneWay = 2 // qualquer nativo no meu alvo (prática unidirecional) ou bot
+)
+
+// Modos de pareamento informados no evento matched
+const (
+	MatchModeExact   = "exact"
+	MatchModeRelaxed = "relaxed"
+	MatchModeOneWay  = "one_way"
+	MatchModeBot     = "bot"
+)
+
+// RelaxationPolicy amplia a busca para pares raros (ex.: fi -> ko) conforme o
+// tempo de espera. O bot, quando configurado, é um usuário comum conectado via
+// WebSocket; ele não entra em fila, só é oferecido no último nível e ganha uma
+// sessão própria em cada sala.
+type RelaxationPolicy struct {
+	SpokenAfter time.Duration
+	OneWayAfter time.Duration
+	BotUserID   string
+}
+
+// Level retorna o nível de relaxamento para quem espera há `waited`
+func (p *RelaxationPolicy) Level(waited time.Duration) int {
+	switch {
+	case p == nil:
+		return RelaxExact
+	case p.OneWayAfter > 0 && waited >= p.OneWayAfter:
+		return RelaxOneWay
+	case p.SpokenAfter > 0 && waited >= p.SpokenAfter:
+		return RelaxSpoken
+	}
+	return RelaxExact
+}
+
+// QueueUpdate é enviado ao cliente como evento queue_update
+type QueueUpdate struct {
+	UserID     string `json:"-"`
+	Reason     string `json:"reason"`
+	RelaxLevel int    `json:"relax_level,omitempty"`
+	Stage      string `json:"stage,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Position   int    `json:"position,omitempty"`
+	ETASeconds *int   `json:"eta_seconds,omitempty"`
+}
+
+// O bot usa uma única conexão, mas cada sala com ele tem um ID de sessão
+// próprio ("<bot>#<id>"), usado como ID do participante em toda a sala
+func newBotSession(botUserID string) string {
+	return botUserID + "#" + uuid.New().String()[:8]
+}
+
+// BotSessionOwner devolve o usuário do bot dono da sessão, ou "" se o ID não for de uma sessão de bot
+func BotSessionOwner(sessionID string) string {
+	owner, _, ok := strings.Cut(sessionID, "#")
+	if !ok {
+		return ""
+	}
+	return owner
+}
+
+func relaxationUpdate(userID string, level int, hasBot bool) QueueUpdate {
+	update := QueueUpdate{UserID: userID, Reason: "relaxed", RelaxLevel: level}
+	switch level {
+	case RelaxSpoken:
+		update.Stage = "spoken_languages"
+		update.Message = "Widening the search to native speakers who practice any language you speak."
+	case RelaxOneWay:
+		update.Stage = "one_way"
+		update.Message = "Offering one-way practice partners: they speak your target language but may not practice yours."
+		if hasBot {
+			update.Message += " A practice bot will step in if no one is available."
+		}
+	}
+	return update
+}
+
+// candidateQueues lista as filas onde procurar parceiro, em ordem de prioridade
+func (s *MatchService) candidateQueues(ctx context.Context, req MatchRequest) ([]string, error) {
+	queues := []string{queueKey(req.TargetLanguage, req.NativeLanguage)}
+	seen := map[string]bool{queues[0]: true}
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			queues = append(queues, key)
+		}
+	}
+
+	if req.RelaxLevel >= RelaxSpoken {
+		for _, lang := range req.SpokenLanguages {
+			if lang != req.TargetLanguage {
+				add(queueKey(req.TargetLanguage, lang))
+			}
+		}
+	}
+	if req.RelaxLevel >= RelaxOneWay {
+		iter := s.Redis.Scan(ctx, 0, queueKey(req.TargetLanguage, "*"), 100).Iterator()
+		for iter.Next(ctx) {
+			add(iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return queues, nil
+}
+
+// MatchMode descreve o pareamento do ponto de vista de `user`
+func MatchMode(user, partner MatchRequest) string {
+	switch {
+	case partner.Bot:
+		return MatchModeBot
+	case partner.NativeLanguage == user.TargetLanguage && partner.TargetLanguage == user.NativeLanguage:
+		return MatchModeExact
+	case partner.NativeLanguage == user.TargetLanguage && containsLang(user.SpokenLanguages, partner.TargetLanguage):
+		return MatchModeRelaxed
+	case partner.TargetLanguage == user.NativeLanguage && containsLang(partner.SpokenLanguages, user.TargetLanguage):
+		return MatchModeRelaxed
+	}
+	return MatchModeOneWay
+}
+
+func containsLang(langs []string, lang string) bool {
+	for _, l := range langs {
+		if strings.EqualFold(l, lang) {
+			return true
+		}
+	}
+	return false
+}
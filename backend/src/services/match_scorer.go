@@ -0,0 +1,72 @@
+package services
+
+import (
+	"math"
+	"strings"
+)
+
+// Scorer dá uma nota de compatibilidade para um candidato; maior é melhor.
+// Só é consultado para candidatos que já atendem ao par de idiomas.
+type Scorer interface {
+	Score(user, candidate MatchRequest) float64
+}
+
+// InterestScorer reproduz a heurística do servidor de desenvolvimento: base 1
+// pelo idioma, +InterestWeight por interesse em comum e +CountryWeight quando
+// os dois são de países diferentes (intercâmbio cultural). Cada faixa de
+// reputação de distância custa ReputationWeight, então reincidentes tendem a
+// encontrar uns aos outros em vez de usuários novos.
+type InterestScorer struct {
+	InterestWeight   float64
+	CountryWeight    float64
+	ReputationWeight float64
+}
+
+var DefaultScorer Scorer = InterestScorer{InterestWeight: 1, CountryWeight: 0.5, ReputationWeight: 3}
+
+func (s InterestScorer) Score(user, candidate MatchRequest) float64 {
+	score := 1 + s.InterestWeight*float64(len(CommonInterests(user, candidate)))
+	if user.Country != "" && candidate.Country != "" && !strings.EqualFold(user.Country, candidate.Country) {
+		score += s.CountryWeight
+	}
+	gap := ReputationBand(user.Reputation) - ReputationBand(candidate.Reputation)
+	score -= s.ReputationWeight * math.Abs(float64(gap))
+	return score
+}
+
+// ReputationBand agrupa a reputação em faixas: 0 reincidente, 1 em observação,
+// 2 normal (usuários novos começam aqui), 3 exemplar
+func ReputationBand(reputation float64) int {
+	switch {
+	case reputation < 50:
+		return 0
+	case reputation < 85:
+		return 1
+	case reputation <= 120:
+		return 2
+	}
+	return 3
+}
+
+// CommonInterests lista os interesses em comum, na ordem do primeiro usuário
+func CommonInterests(a, b MatchRequest) []string {
+	theirs := make(map[string]bool, len(b.Interests))
+	for _, i := range b.Interests {
+		theirs[normalizeInterest(i)] = true
+	}
+
+	common := []string{}
+	seen := make(map[string]bool)
+	for _, i := range a.Interests {
+		n := normalizeInterest(i)
+		if theirs[n] && !seen[n] {
+			seen[n] = true
+			common = append(common, i)
+		}
+	}
+	return common
+}
+
+func normalizeInterest(i string) string {
+	return strings.ToLower(strings.TrimSpace(i))
+}
@@ -1,47 +1,590 @@
-
 package services
 
 import (
 	"context"
-	"fmt"
-	"github.com/redis/go-redis/v9"
 	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+const roomTTL = 6 * time.Hour
+
+// Layout no Redis:
+//
+//	queue:<native>:<target>  ZSET  membro = userID, score = entrada na fila
+//	match:entry:<userID>     STRING MatchRequest em JSON
+//	match:queues:<userID>    SET   filas em que o usuário está
 type MatchService struct {
 	Redis *redis.Client
+
+	// Scorer escolhe o melhor candidato dentro de Window entradas de cada fila candidata
+	Scorer Scorer
+	Window int
+
+	// Relaxation é opcional; sem ela só o par exato é aceito
+	Relaxation *RelaxationPolicy
+
+	// Blocks é a fonte persistente dos bloqueios (Postgres); recarregada a cada join
+	Blocks BlockSource
+
+	// Reputation fornece a reputação usada pelo Scorer; sem ela todos valem DefaultReputation
+	Reputation ReputationSource
+
+	// RecentCooldown evita reparear a mesma dupla logo depois de uma conversa (0 desativa)
+	RecentCooldown time.Duration
+}
+
+// BlockSource lista todos os usuários com quem userID não pode ser pareado,
+// em qualquer direção do bloqueio
+type BlockSource interface {
+	Exclusions(userID string) ([]string, error)
+}
+
+type ReputationSource interface {
+	Reputation(userID string) (float64, error)
 }
 
 type MatchRequest struct {
-	UserID         string `json:"user_id"`
-	NativeLanguage string `json:"native_lang"`
-	TargetLanguage string `json:"target_lang"`
+	UserID         string   `json:"user_id"`
+	NativeLanguage string   `json:"native_lang"`
+	TargetLanguage string   `json:"target_lang"`
+	Interests      []string `json:"interests,omitempty"`
+	Country        string   `json:"country,omitempty"`
+
+	// Outros idiomas que o usuário fala, usados no relaxamento da fila
+	SpokenLanguages []string `json:"spoken_langs,omitempty"`
+	JoinedAt        int64    `json:"joined_at,omitempty"`
+	RelaxLevel      int      `json:"relax_level,omitempty"`
+	Bot             bool     `json:"bot,omitempty"`
+
+	// Preenchida pelo servidor; o valor enviado pelo cliente é ignorado
+	Reputation float64 `json:"reputation,omitempty"`
+}
+
+func queueKey(native, target string) string {
+	return fmt.Sprintf("queue:%s:%s", native, target)
 }
 
-func (s *MatchService) AddToQueue(req MatchRequest) error {
+func entryKey(userID string) string  { return "match:entry:" + userID }
+func queuesKey(userID string) string { return "match:queues:" + userID }
+
+// removeScript tira o usuário de todas as filas em que está, atomicamente
+// (o estado "searching" vai junto; o estado "matched" fica até a sala fechar)
+var removeScript = redis.NewScript(`
+local queues = redis.call('SMEMBERS', KEYS[2])
+for _, q in ipairs(queues) do
+	redis.call('ZREM', q, ARGV[1])
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+if redis.call('HGET', KEYS[3], 'status') == 'searching' then
+	redis.call('DEL', KEYS[3])
+end
+return #queues
+`)
+
+// matchScript executa "match-or-enqueue" de forma atômica.
+//
+//	KEYS[1] fila do usuário, KEYS[2] entrada, KEYS[3] set de filas,
+//	KEYS[4..] filas candidatas em ordem de prioridade
+//	ARGV[1] userID, ARGV[2] entrada JSON, ARGV[3] score, ARGV[4] janela, ARGV[5] "1" para enfileirar,
+//	ARGV[6..] candidatos preferidos (ranqueados pelo Scorer)
+//
+// Os preferidos são tentados primeiro; se todos já tiverem saído, as filas são
+// varridas em ordem de chegada, então quem entrou depois da leitura ainda é visto.
+// Bloqueios (match:exclude:<id>) valem nos dois sentidos; duplas em cooldown
+// (match:recent:<a>:<b>) são puladas e o resultado indica se isso aconteceu.
+// Nunca pareia o usuário consigo mesmo e, como ambas as entradas são removidas
+// no mesmo script, ninguém pode ser pareado duas vezes. Ao enfileirar, o usuário
+// sai das filas de entradas anteriores, então a entrada sempre bate com a fila.
+var matchScript = redis.NewScript(`
+local uid = ARGV[1]
+local window = tonumber(ARGV[4])
+
+local function drop(id)
+	local queues = redis.call('SMEMBERS', 'match:queues:' .. id)
+	for _, q in ipairs(queues) do
+		redis.call('ZREM', q, id)
+	end
+	redis.call('DEL', 'match:entry:' .. id, 'match:queues:' .. id)
+end
+
+local function excluded(cand)
+	return redis.call('SISMEMBER', 'match:exclude:' .. uid, cand) == 1
+		or redis.call('SISMEMBER', 'match:exclude:' .. cand, uid) == 1
+end
+
+local cooled = '0'
+local function eligible(cand)
+	if cand == uid or excluded(cand) then
+		return false
+	end
+	local pair = uid .. ':' .. cand
+	if cand < uid then
+		pair = cand .. ':' .. uid
+	end
+	if redis.call('EXISTS', 'match:recent:' .. pair) == 1 then
+		cooled = '1'
+		return false
+	end
+	return true
+end
+
+if ARGV[5] ~= '1' and redis.call('EXISTS', KEYS[2]) == 0 then
+	return {'gone'}
+end
+
+for i = 6, #ARGV do
+	local cand = ARGV[i]
+	if eligible(cand) then
+		local entry = redis.call('GET', 'match:entry:' .. cand)
+		if entry then
+			drop(cand)
+			drop(uid)
+			return {'matched', cand, entry}
+		end
+	end
+end
+
+for i = 4, #KEYS do
+	local candidates = redis.call('ZRANGE', KEYS[i], 0, window - 1)
+	for _, cand in ipairs(candidates) do
+		if eligible(cand) then
+			local entry = redis.call('GET', 'match:entry:' .. cand)
+			if entry then
+				drop(cand)
+				drop(uid)
+				return {'matched', cand, entry}
+			end
+			-- Entrada órfã: o usuário já saiu
+			redis.call('ZREM', KEYS[i], cand)
+		end
+	end
+end
+
+if ARGV[5] == '1' then
+	-- Quem entra de novo com outro par de idiomas sai da fila antiga
+	for _, q in ipairs(redis.call('SMEMBERS', KEYS[3])) do
+		if q ~= KEYS[1] then
+			redis.call('ZREM', q, uid)
+			redis.call('SREM', KEYS[3], q)
+		end
+	end
+	redis.call('ZADD', KEYS[1], 'NX', ARGV[3], uid)
+	redis.call('SET', KEYS[2], ARGV[2])
+	redis.call('SADD', KEYS[3], KEYS[1])
+	return {'queued', cooled}
+end
+return {'waiting', cooled}
+`)
+
+const (
+	defaultMatchWindow = 50
+	maxInterests       = 10
+)
+
+const (
+	MatchStatusMatched = "matched"
+	MatchStatusQueued  = "queued"
+	MatchStatusWaiting = "waiting"
+)
+
+// MatchResult é sempre definitivo: ou há parceiro, ou o usuário está na fila
+type MatchResult struct {
+	Status          string
+	Partner         *MatchRequest
+	RoomID          string
+	CommonInterests []string
+
+	// Cooldown indica que só havia parceiros recentes disponíveis (espera esperada)
+	Cooldown bool
+}
+
+// MatchOrEnqueue procura um parceiro na fila inversa e, se não houver, enfileira
+// o usuário — tudo numa única operação atômica no Redis.
+func (s *MatchService) MatchOrEnqueue(req MatchRequest) (*MatchResult, error) {
+	if len(req.Interests) > maxInterests {
+		req.Interests = req.Interests[:maxInterests]
+	}
+	req.JoinedAt = time.Now().Unix()
+	req.RelaxLevel = RelaxExact
+	req.Bot = false
+	req.Reputation = DefaultReputation
+	if s.Reputation != nil {
+		if rep, err := s.Reputation.Reputation(req.UserID); err == nil {
+			req.Reputation = rep
+		} else {
+			log.Printf("⚠️ Reputation lookup failed for %s: %v", req.UserID, err)
+		}
+	}
+
+	// Sem a lista de bloqueios não arriscamos parear (falha fechada)
+	if s.Blocks != nil {
+		ids, err := s.Blocks.Exclusions(req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.SetExclusions(req.UserID, ids); err != nil {
+			return nil, err
+		}
+	}
+	result, err := s.runMatch(req, true)
+	if err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
-	// Chave da fila baseada no idioma alvo para encontrar compatibilidade inversa
-	queueKey := fmt.Sprintf("queue:%s:%s", req.NativeLanguage, req.TargetLanguage)
-	
-	val, _ := json.Marshal(req)
-	return s.Redis.ZAdd(ctx, queueKey, redis.Z{
-		Score:  float64(time.Now().Unix()),
-		Member: val,
-	}).Err()
+	partner := result.Partner
+	if partner == nil {
+		if err := s.markSearching(ctx, req); err != nil {
+			log.Printf("❌ Failed to store queue state for %s: %v", req.UserID, err)
+		}
+		return result, nil
+	}
+
+	result.RoomID = NewRoomID(req.UserID, partner.UserID)
+	s.recordMatch(ctx, req, *partner, result.RoomID)
+	result.CommonInterests = CommonInterests(req, *partner)
+	return result, nil
 }
 
-func (s *MatchService) FindMatch(req MatchRequest) (*MatchRequest, error) {
+func (s *MatchService) runMatch(req MatchRequest, enqueue bool) (*MatchResult, error) {
 	ctx := context.Background()
 	// Procuramos alguém que fale o que eu quero aprender e queira aprender o que eu falo
-	inverseKey := fmt.Sprintf("queue:%s:%s", req.TargetLanguage, req.NativeLanguage)
-	
-	vals, err := s.Redis.ZPopMin(ctx, inverseKey, 1).Result()
-	if err != nil || len(vals) == 0 {
-		return nil, nil
+	own := queueKey(req.NativeLanguage, req.TargetLanguage)
+	candidates, err := s.candidateQueues(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	preferred, err := s.rankCandidates(ctx, req, candidates)
+	if err != nil {
+		return nil, err
 	}
 
+	val, _ := json.Marshal(req)
+	flag := "0"
+	if enqueue {
+		flag = "1"
+	}
+	args := []interface{}{req.UserID, val, req.JoinedAt, s.window(), flag}
+	for _, id := range preferred {
+		args = append(args, id)
+	}
+
+	keys := append([]string{own, entryKey(req.UserID), queuesKey(req.UserID)}, candidates...)
+	res, err := matchScript.Run(ctx, s.Redis, keys, args...).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	if res[0] != MatchStatusMatched {
+		return &MatchResult{Status: res[0], Cooldown: len(res) > 1 && res[1] == "1"}, nil
+	}
 	var partner MatchRequest
-	json.Unmarshal([]byte(vals[0].Member.(string)), &partner)
-	return &partner, nil
+	if err := json.Unmarshal([]byte(res[2]), &partner); err != nil {
+		return nil, err
+	}
+	return &MatchResult{Status: MatchStatusMatched, Partner: &partner}, nil
+}
+
+// rankCandidates lê a janela de cada fila candidata e ordena: filas de maior
+// prioridade primeiro, depois pelo Scorer (empates mantêm a ordem de chegada)
+func (s *MatchService) rankCandidates(ctx context.Context, req MatchRequest, queues []string) ([]string, error) {
+	scorer := s.Scorer
+	if scorer == nil {
+		scorer = DefaultScorer
+	}
+
+	type scored struct {
+		id       string
+		priority int
+		score    float64
+	}
+	var candidates []scored
+	seen := make(map[string]bool)
+
+	for priority, queue := range queues {
+		ids, err := s.Redis.ZRange(ctx, queue, 0, int64(s.window()-1)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = entryKey(id)
+		}
+		entries, err := s.Redis.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, raw := range entries {
+			data, ok := raw.(string)
+			if !ok || ids[i] == req.UserID || seen[ids[i]] {
+				continue
+			}
+			var cand MatchRequest
+			if json.Unmarshal([]byte(data), &cand) != nil {
+				continue
+			}
+			seen[ids[i]] = true
+			candidates = append(candidates, scored{id: ids[i], priority: priority, score: scorer.Score(req, cand)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	ranked := make([]string, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = c.id
+	}
+	return ranked, nil
+}
+
+func (s *MatchService) window() int {
+	if s.Window > 0 {
+		return s.Window
+	}
+	return defaultMatchWindow
+}
+
+// MatchPair é um pareamento feito pelo matchmaker em background
+type MatchPair struct {
+	User    MatchRequest `json:"user"`
+	Partner MatchRequest `json:"partner"`
+	RoomID  string       `json:"room_id"`
+}
+
+// MatchEvent trafega entre instâncias pelo pub/sub: um pareamento ou uma
+// atualização de fila para um usuário específico
+type MatchEvent struct {
+	Pair   *MatchPair   `json:"pair,omitempty"`
+	Update *QueueUpdate `json:"update,omitempty"`
+	UserID string       `json:"user_id,omitempty"`
+}
+
+const matchEventsChannel = "match:events"
+
+// leaderScript renova a liderança se já for nossa ou tenta adquiri-la
+var leaderScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// AcquireLeadership garante que só uma instância rode o sweep por vez
+func (s *MatchService) AcquireLeadership(ctx context.Context, instanceID string, ttl time.Duration) (bool, error) {
+	n, err := leaderScript.Run(ctx, s.Redis, []string{"match:leader"}, instanceID, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Sweep percorre todas as filas queue:* e pareia usuários compatíveis que
+// continuam esperando. Cada pareamento usa o mesmo script atômico do join; quem
+// passou de um limite da RelaxationPolicy sobe de nível e recebe um queue_update.
+func (s *MatchService) Sweep(ctx context.Context) ([]MatchEvent, error) {
+	var keys []string
+	iter := s.Redis.Scan(ctx, 0, "queue:*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []MatchEvent
+	for _, key := range keys {
+		waiting, err := s.Redis.ZRange(ctx, key, 0, int64(s.window()-1)).Result()
+		if err != nil {
+			return events, err
+		}
+		for _, userID := range waiting {
+			data, err := s.Redis.Get(ctx, entryKey(userID)).Bytes()
+			if err != nil {
+				continue
+			}
+			var req MatchRequest
+			if json.Unmarshal(data, &req) != nil {
+				continue
+			}
+
+			if level := s.Relaxation.Level(time.Since(time.Unix(req.JoinedAt, 0))); level > req.RelaxLevel {
+				req.RelaxLevel = level
+				val, _ := json.Marshal(req)
+				// SET XX: se o usuário acabou de sair, não recriamos a entrada
+				if ok, _ := s.Redis.SetXX(ctx, entryKey(userID), val, 0).Result(); !ok {
+					continue
+				}
+				update := relaxationUpdate(userID, level, s.Relaxation.BotUserID != "")
+				events = append(events, MatchEvent{UserID: userID, Update: &update})
+			}
+
+			result, err := s.runMatch(req, false)
+			if err != nil {
+				log.Printf("❌ Sweep failed for %s: %v", userID, err)
+				continue
+			}
+			if partner := result.Partner; partner != nil {
+				roomID := NewRoomID(req.UserID, partner.UserID)
+				s.recordMatch(ctx, req, *partner, roomID)
+				events = append(events, MatchEvent{Pair: &MatchPair{User: req, Partner: *partner, RoomID: roomID}})
+				continue
+			}
+			if result.Status == MatchStatusWaiting && req.RelaxLevel >= RelaxOneWay {
+				if pair := s.matchBot(ctx, req); pair != nil {
+					events = append(events, MatchEvent{Pair: pair})
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// matchBot pareia com uma sessão nova do bot configurado; a remoção atômica da
+// fila garante que o usuário não foi pareado por outro caminho nesse meio tempo
+func (s *MatchService) matchBot(ctx context.Context, req MatchRequest) *MatchPair {
+	if s.Relaxation == nil || s.Relaxation.BotUserID == "" {
+		return nil
+	}
+	removed, err := removeScript.Run(ctx, s.Redis,
+		[]string{entryKey(req.UserID), queuesKey(req.UserID), stateKey(req.UserID)}, req.UserID).Int()
+	if err != nil || removed == 0 {
+		return nil
+	}
+
+	bot := MatchRequest{
+		UserID:         newBotSession(s.Relaxation.BotUserID),
+		NativeLanguage: req.TargetLanguage,
+		TargetLanguage: req.NativeLanguage,
+		Bot:            true,
+	}
+	roomID := NewRoomID(req.UserID, bot.UserID)
+	s.recordMatch(ctx, req, bot, roomID)
+	return &MatchPair{User: req, Partner: bot, RoomID: roomID}
+}
+
+// PublishEvent avisa todas as instâncias, já que os usuários podem estar conectados em qualquer uma
+func (s *MatchService) PublishEvent(ctx context.Context, event MatchEvent) error {
+	data, _ := json.Marshal(event)
+	return s.Redis.Publish(ctx, matchEventsChannel, data).Err()
+}
+
+// SubscribeEvents entrega os eventos publicados por qualquer instância
+func (s *MatchService) SubscribeEvents(ctx context.Context) <-chan MatchEvent {
+	return subscribe[MatchEvent](ctx, s.Redis, matchEventsChannel)
+}
+
+const exclusionTTL = 24 * time.Hour
+
+func excludeKey(userID string) string { return "match:exclude:" + userID }
+
+// SetExclusions substitui o conjunto de usuários bloqueados para userID
+func (s *MatchService) SetExclusions(userID string, ids []string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	pipe.Del(ctx, excludeKey(userID))
+	if len(ids) > 0 {
+		pipe.SAdd(ctx, excludeKey(userID), ids)
+		pipe.Expire(ctx, excludeKey(userID), exclusionTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// AddExclusion aplica um bloqueio novo imediatamente, nos dois sentidos
+func (s *MatchService) AddExclusion(userID, otherID string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	for _, pair := range [][2]string{{userID, otherID}, {otherID, userID}} {
+		pipe.SAdd(ctx, excludeKey(pair[0]), pair[1])
+		pipe.Expire(ctx, excludeKey(pair[0]), exclusionTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RemoveFromQueue remove todas as entradas do usuário, em qualquer fila
+func (s *MatchService) RemoveFromQueue(userID string) error {
+	return removeScript.Run(context.Background(), s.Redis,
+		[]string{entryKey(userID), queuesKey(userID), stateKey(userID)}, userID).Err()
+}
+
+// RegisterRoom associa cada participante à sala para consultas via REST
+func (s *MatchService) RegisterRoom(roomID string, userIDs ...string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	for _, id := range userIDs {
+		pipe.Set(ctx, "room:user:"+id, roomID, roomTTL)
+	}
+	// Idempotente: várias instâncias podem registrar a mesma sala
+	pipe.Del(ctx, "room:members:"+roomID)
+	pipe.RPush(ctx, "room:members:"+roomID, userIDs)
+	pipe.Expire(ctx, "room:members:"+roomID, roomTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ReleaseRoom desfaz o registro da sala quando ela é fechada
+func (s *MatchService) ReleaseRoom(roomID string, userIDs ...string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	for _, id := range userIDs {
+		pipe.Del(ctx, "room:user:"+id, stateKey(id))
+	}
+	pipe.Del(ctx, "room:members:"+roomID, ownerKey(roomID))
+	// O cooldown conta a partir do fim da conversa
+	s.markRecent(ctx, pipe, userIDs...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// AddRoomMember registra um participante a mais sem reescrever a lista de
+// membros, que outras instâncias podem estar alterando ao mesmo tempo (mesas de grupo)
+func (s *MatchService) AddRoomMember(roomID, userID string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	pipe.Set(ctx, "room:user:"+userID, roomID, roomTTL)
+	pipe.LRem(ctx, "room:members:"+roomID, 0, userID)
+	pipe.RPush(ctx, "room:members:"+roomID, userID)
+	pipe.Expire(ctx, "room:members:"+roomID, roomTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RemoveRoomMember tira só esse participante da sala
+func (s *MatchService) RemoveRoomMember(roomID, userID string) error {
+	ctx := context.Background()
+	pipe := s.Redis.TxPipeline()
+	pipe.Del(ctx, "room:user:"+userID, stateKey(userID))
+	pipe.LRem(ctx, "room:members:"+roomID, 0, userID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// CurrentRoom retorna a sala atual do usuário ou "" se não houver
+func (s *MatchService) CurrentRoom(userID string) (string, error) {
+	roomID, err := s.Redis.Get(context.Background(), "room:user:"+userID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return roomID, err
+}
+
+// RoomMembers lista os participantes registrados na sala, na ordem de entrada
+func (s *MatchService) RoomMembers(roomID string) ([]string, error) {
+	return s.Redis.LRange(context.Background(), "room:members:"+roomID, 0, -1).Result()
 }
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const throughputSamples = 20
+
+// Estado por usuário no Redis:
+//
+//	match:state:<userID>        HASH  status, queue, room_id, partner_id, partner_language
+//	match:throughput:<queueKey> LIST  instantes (ms) dos últimos pareamentos que saíram da fila
+func stateKey(userID string) string { return "match:state:" + userID }
+
+// MatchState é a resposta de /v1/match/status
+type MatchState struct {
+	Status          string `json:"status"`
+	Position        int    `json:"position,omitempty"`
+	ETASeconds      *int   `json:"eta_seconds,omitempty"`
+	RoomID          string `json:"room_id,omitempty"`
+	PartnerID       string `json:"partner_id,omitempty"`
+	PartnerLanguage string `json:"partner_language,omitempty"`
+	LiveKitToken    string `json:"livekit_token,omitempty"`
+}
+
+const (
+	MatchStateSearching = "searching"
+	MatchStateMatched   = "matched"
+)
+
+// NewRoomID gera o identificador da sala de um pareamento; a ordem segue o
+// MatchPair e o sufixo aleatório separa conversas repetidas da mesma dupla
+func NewRoomID(userID, partnerID string) string {
+	return "room_" + userID + "_" + partnerID + "_" + uuid.New().String()[:8]
+}
+
+func (s *MatchService) markSearching(ctx context.Context, req MatchRequest) error {
+	key := stateKey(req.UserID)
+	pipe := s.Redis.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "status", MatchStateSearching, "queue", queueKey(req.NativeLanguage, req.TargetLanguage))
+	pipe.Expire(ctx, key, roomTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// recordMatch grava o estado "matched" dos dois lados e a vazão das filas de origem
+func (s *MatchService) recordMatch(ctx context.Context, user, partner MatchRequest, roomID string) {
+	now := time.Now().UnixMilli()
+
+	pipe := s.Redis.TxPipeline()
+	for _, side := range [][2]MatchRequest{{user, partner}, {partner, user}} {
+		me, other := side[0], side[1]
+		if me.Bot {
+			continue
+		}
+		key := stateKey(me.UserID)
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			"status", MatchStateMatched,
+			"room_id", roomID,
+			"partner_id", other.UserID,
+			"partner_language", other.NativeLanguage,
+		)
+		pipe.Expire(ctx, key, roomTTL)
+
+		tp := "match:throughput:" + queueKey(me.NativeLanguage, me.TargetLanguage)
+		pipe.LPush(ctx, tp, now)
+		pipe.LTrim(ctx, tp, 0, throughputSamples-1)
+		pipe.Expire(ctx, tp, roomTTL)
+	}
+	if !user.Bot && !partner.Bot {
+		s.markRecent(ctx, pipe, user.UserID, partner.UserID)
+	}
+	pipe.Exec(ctx)
+}
+
+// Status devolve o estado do usuário; nil quando ele não está na fila nem em sala
+func (s *MatchService) Status(ctx context.Context, userID string) (*MatchState, error) {
+	fields, err := s.Redis.HGetAll(ctx, stateKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	state := &MatchState{
+		Status:          fields["status"],
+		RoomID:          fields["room_id"],
+		PartnerID:       fields["partner_id"],
+		PartnerLanguage: fields["partner_language"],
+	}
+	if state.Status != MatchStateSearching {
+		return state, nil
+	}
+
+	queue := fields["queue"]
+	rank, err := s.Redis.ZRank(ctx, queue, userID).Result()
+	if err == redis.Nil {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.Position = int(rank) + 1
+	state.ETASeconds = s.estimateWait(ctx, queue, state.Position)
+	return state, nil
+}
+
+// estimateWait usa a vazão recente da fila: posição / (pareamentos por segundo)
+func (s *MatchService) estimateWait(ctx context.Context, queue string, position int) *int {
+	samples, err := s.Redis.LRange(ctx, "match:throughput:"+queue, 0, -1).Result()
+	if err != nil || len(samples) < 2 {
+		return nil
+	}
+
+	newest, _ := strconv.ParseInt(samples[0], 10, 64)
+	oldest, _ := strconv.ParseInt(samples[len(samples)-1], 10, 64)
+	span := time.Duration(newest-oldest) * time.Millisecond
+	if span <= 0 {
+		return nil
+	}
+
+	perSecond := float64(len(samples)-1) / span.Seconds()
+	eta := int(float64(position) / perSecond)
+	return &eta
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxRatingTags    = 5
+	maxRatingTagLen  = 32
+	maxRatingTextLen = 500
+	positiveRating   = 4
+)
+
+var (
+	ErrInvalidRating   = errors.New("invalid_score")
+	ErrSessionNotFound = errors.New("session_not_found")
+	ErrNotParticipant  = errors.New("not_a_participant")
+	ErrAlreadyRated    = errors.New("already_rated")
+)
+
+type RatingInput struct {
+	Score int      `json:"score"`
+	Tags  []string `json:"tags"`
+	Text  string   `json:"text"`
+}
+
+// RatingStats agrega as avaliações recebidas por um usuário
+type RatingStats struct {
+	UserID  string         `json:"user_id"`
+	Count   int64          `json:"count"`
+	Average float64        `json:"average"`
+	Scores  map[int]int64  `json:"scores"`
+	Tags    map[string]int `json:"tags"`
+}
+
+type RatingService struct {
+	DB         *gorm.DB
+	Reputation *ReputationService
+}
+
+// Rate registra a avaliação de raterID sobre o outro participante da sessão;
+// cada participante avalia uma única vez por sessão (cada conversa tem a sua)
+func (s *RatingService) Rate(sessionID, raterID string, input RatingInput) (*models.Rating, error) {
+	if input.Score < 1 || input.Score > 5 {
+		return nil, ErrInvalidRating
+	}
+
+	var session models.Session
+	if err := s.DB.First(&session, "id = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	var rated string
+	switch raterID {
+	case session.UserID:
+		rated = session.PartnerID
+	case session.PartnerID:
+		rated = session.UserID
+	}
+	if rated == "" {
+		return nil, ErrNotParticipant
+	}
+
+	// Corta por caracteres para não quebrar um UTF-8 no meio
+	text := strings.TrimSpace(input.Text)
+	if runes := []rune(text); len(runes) > maxRatingTextLen {
+		text = string(runes[:maxRatingTextLen])
+	}
+	rating := models.Rating{
+		SessionID:   sessionID,
+		RaterID:     raterID,
+		RatedUserID: rated,
+		Score:       input.Score,
+		Tags:        normalizeTags(input.Tags),
+		Text:        text,
+	}
+	// O índice único (session_id, rater_id) garante uma avaliação por participante,
+	// inclusive quando duas requisições chegam ao mesmo tempo
+	result := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rating)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyRated
+	}
+
+	if rating.Score >= positiveRating && s.Reputation != nil {
+		s.Reputation.Record(rated, ReputationPositiveRating, rating.ID)
+	}
+	return &rating, nil
+}
+
+// RateRoom avalia a sessão ligada à sala (usado pelo WebSocket). Mesas de
+// grupo não gravam sessão e respondem ErrSessionNotFound.
+func (s *RatingService) RateRoom(roomID, raterID string, input RatingInput) (*models.Rating, error) {
+	var session models.Session
+	if err := s.DB.Select("id").First(&session, "room_id = ?", roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return s.Rate(session.ID, raterID, input)
+}
+
+func (s *RatingService) Stats(userID string) (*RatingStats, error) {
+	var ratings []models.Rating
+	if err := s.DB.Select("score", "tags").Where("rated_user_id = ?", userID).Find(&ratings).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &RatingStats{UserID: userID, Scores: map[int]int64{}, Tags: map[string]int{}}
+	total := 0
+	for _, r := range ratings {
+		stats.Count++
+		stats.Scores[r.Score]++
+		total += r.Score
+		for _, tag := range r.Tags {
+			stats.Tags[tag]++
+		}
+	}
+	if stats.Count > 0 {
+		stats.Average = float64(total) / float64(stats.Count)
+	}
+	return stats, nil
+}
+
+// normalizeTags deixa as tags em minúsculas, sem duplicatas e dentro dos limites
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxRatingTagLen || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+		if len(out) == maxRatingTags {
+			break
+		}
+	}
+	return out
+}
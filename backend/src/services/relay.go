@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cada sala vive na memória de uma única instância (a dona). As demais falam
+// com ela pelo canal ws:relay:
+//
+//	room:owner:<roomID>   STRING  instância dona da sala
+//	ws:online:<userID>    STRING  instância em que o usuário está conectado
+const relayChannel = "ws:relay"
+
+// RelayMessage leva uma mensagem de WebSocket entre instâncias. Com To, é uma
+// entrega para um usuário conectado em outra instância; com Instance, é uma
+// mensagem de From para a instância dona da sala dele.
+type RelayMessage struct {
+	To         string          `json:"to,omitempty"`
+	Instance   string          `json:"instance,omitempty"`
+	From       string          `json:"from,omitempty"`
+	Disconnect bool            `json:"disconnect,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+func ownerKey(roomID string) string  { return "room:owner:" + roomID }
+func onlineKey(userID string) string { return "ws:online:" + userID }
+
+// ClaimRoom reserva a sala para a instância; só quem reivindica primeiro a abre
+func (s *MatchService) ClaimRoom(roomID, instanceID string) (bool, error) {
+	return s.Redis.SetNX(context.Background(), ownerKey(roomID), instanceID, roomTTL).Result()
+}
+
+// RoomOwner retorna a instância dona da sala ou "" (mesas de grupo não têm dona)
+func (s *MatchService) RoomOwner(roomID string) (string, error) {
+	owner, err := s.Redis.Get(context.Background(), ownerKey(roomID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return owner, err
+}
+
+// compareDelScript apaga a chave só se ela ainda tiver o valor esperado
+var compareDelScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// SetOnline marca o usuário como conectado na instância
+func (s *MatchService) SetOnline(userID, instanceID string) error {
+	return s.Redis.Set(context.Background(), onlineKey(userID), instanceID, roomTTL).Err()
+}
+
+// ClearOnline desfaz SetOnline, a menos que o usuário já tenha reconectado em outra instância
+func (s *MatchService) ClearOnline(userID, instanceID string) error {
+	return compareDelScript.Run(context.Background(), s.Redis, []string{onlineKey(userID)}, instanceID).Err()
+}
+
+// IsOnline diz se o usuário está conectado em alguma instância
+func (s *MatchService) IsOnline(userID string) bool {
+	n, _ := s.Redis.Exists(context.Background(), onlineKey(userID)).Result()
+	return n == 1
+}
+
+// PublishRelay envia a mensagem para todas as instâncias
+func (s *MatchService) PublishRelay(ctx context.Context, msg RelayMessage) error {
+	data, _ := json.Marshal(msg)
+	return s.Redis.Publish(ctx, relayChannel, data).Err()
+}
+
+// SubscribeRelay entrega as mensagens publicadas por qualquer instância
+func (s *MatchService) SubscribeRelay(ctx context.Context) <-chan RelayMessage {
+	return subscribe[RelayMessage](ctx, s.Redis, relayChannel)
+}
+
+// subscribe decodifica as mensagens JSON do canal até ctx acabar
+func subscribe[T any](ctx context.Context, rdb *redis.Client, channel string) <-chan T {
+	out := make(chan T)
+	sub := rdb.Subscribe(ctx, channel)
+	// Espera a confirmação para não perder o que for publicado logo em seguida
+	sub.Receive(ctx)
+
+	go func() {
+		defer close(out)
+		for msg := range sub.Channel() {
+			var v T
+			if json.Unmarshal([]byte(msg.Payload), &v) != nil {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		sub.Close()
+	}()
+	return out
+}
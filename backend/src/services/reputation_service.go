@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Motivos aceitos no ledger de reputação
+const (
+	ReputationReportReceived   = "report_received"
+	ReputationReportUpheld     = "report_upheld"
+	ReputationSessionCompleted = "session_completed"
+	ReputationEarlyDisconnect  = "early_disconnect"
+	ReputationPositiveRating   = "positive_rating"
+)
+
+// ReputationDeltas é o peso de cada motivo. Uma denúncia só pesa depois de
+// confirmada pela moderação (report_received e report_upheld juntos): denúncias
+// falsas em massa não derrubam ninguém.
+var ReputationDeltas = map[string]float64{
+	ReputationReportReceived:   -5,
+	ReputationReportUpheld:     -20,
+	ReputationSessionCompleted: 1,
+	ReputationEarlyDisconnect:  -2,
+	ReputationPositiveRating:   3,
+}
+
+const (
+	DefaultReputation      = 100.0
+	maxReputation          = 200.0
+	defaultReputationDecay = 30 * 24 * time.Hour
+	reportCooldown         = 24 * time.Hour
+	// Variações menores que isso (o decaimento contínuo) não reescrevem users.reputation
+	reputationWriteEpsilon = 0.01
+)
+
+var (
+	ErrUnknownReputationReason = errors.New("unknown_reputation_reason")
+	ErrCannotReportSelf        = errors.New("cannot_report_self")
+	ErrReportNotFound          = errors.New("report_not_found")
+)
+
+// ReputationService mantém o ledger (models.ReputationEvent) e a coluna derivada
+// users.reputation. Cada ajuste perde metade do peso a cada HalfLife, então
+// usuários punidos recuperam a reputação com o tempo.
+type ReputationService struct {
+	DB       *gorm.DB
+	HalfLife time.Duration
+}
+
+// Record adiciona um ajuste e recalcula a reputação. refID identifica a origem
+// (report, sala, avaliação) e torna a chamada idempotente.
+func (s *ReputationService) Record(userID, reason, refID string) (float64, error) {
+	delta, ok := ReputationDeltas[reason]
+	if !ok {
+		return 0, ErrUnknownReputationReason
+	}
+
+	event := models.ReputationEvent{UserID: userID, Reason: reason, RefID: refID, Delta: delta}
+	if err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error; err != nil {
+		return 0, err
+	}
+	return s.Reputation(userID)
+}
+
+// Reputation recalcula a reputação a partir do ledger e atualiza users.reputation
+// só quando o valor mudou, já que é consultada a cada entrada na fila
+func (s *ReputationService) Reputation(userID string) (float64, error) {
+	var events []models.ReputationEvent
+	if err := s.DB.Where("user_id = ?", userID).Find(&events).Error; err != nil {
+		return DefaultReputation, err
+	}
+
+	score := DecayedReputation(events, time.Now(), s.halfLife())
+	err := s.DB.Model(&models.User{}).
+		Where("id = ? AND ABS(reputation - ?) >= ?", userID, score, reputationWriteEpsilon).
+		Update("reputation", score).Error
+	return score, err
+}
+
+// Report registra uma denúncia, que só afeta a reputação em UpholdReport;
+// denúncias repetidas do mesmo usuário contra o mesmo alvo dentro de
+// reportCooldown devolvem a anterior
+func (s *ReputationService) Report(reporterID, reportedID, reason, details string) (*models.Report, error) {
+	if reporterID == reportedID {
+		return nil, ErrCannotReportSelf
+	}
+
+	var recent models.Report
+	err := s.DB.Where("reporter_id = ? AND reported_user_id = ? AND created_at > ?", reporterID, reportedID, time.Now().Add(-reportCooldown)).
+		First(&recent).Error
+	if err == nil {
+		return &recent, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	report := models.Report{
+		ReporterID:     reporterID,
+		ReportedUserID: reportedID,
+		Reason:         reason,
+		Details:        details,
+		Status:         "open",
+	}
+	if err := s.DB.Create(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// UpholdReport confirma uma denúncia após moderação e aplica as penalidades;
+// confirmar de novo não pesa outra vez
+func (s *ReputationService) UpholdReport(reportID string) (*models.Report, error) {
+	var report models.Report
+	if err := s.DB.First(&report, "id = ?", reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.DB.Model(&report).Update("status", "upheld").Error; err != nil {
+		return nil, err
+	}
+	for _, reason := range []string{ReputationReportReceived, ReputationReportUpheld} {
+		if _, err := s.Record(report.ReportedUserID, reason, report.ID); err != nil {
+			return &report, err
+		}
+	}
+	return &report, nil
+}
+
+func (s *ReputationService) halfLife() time.Duration {
+	if s.HalfLife > 0 {
+		return s.HalfLife
+	}
+	return defaultReputationDecay
+}
+
+// DecayedReputation soma os ajustes ponderados pela idade (meia-vida halfLife)
+// a partir da reputação inicial, limitada a [0, maxReputation]
+func DecayedReputation(events []models.ReputationEvent, now time.Time, halfLife time.Duration) float64 {
+	score := DefaultReputation
+	for _, e := range events {
+		age := now.Sub(e.CreatedAt)
+		if age < 0 {
+			age = 0
+		}
+		score += e.Delta * math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+	return math.Max(0, math.Min(maxReputation, score))
+}
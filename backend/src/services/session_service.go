@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/gorm"
+)
+
+// SessionService mantém o ciclo de vida de models.Session (uma linha por sala)
+type SessionService struct {
+	DB *gorm.DB
+}
+
+// Start cria a sessão da sala se ainda não existir; chamadas repetidas são idempotentes.
+// Cada pareamento tem uma sala nova, então uma sessão encerrada nunca é reaberta.
+func (s *SessionService) Start(roomID string, at time.Time, userIDs ...string) (*models.Session, error) {
+	var session models.Session
+	err := s.DB.Where("room_id = ?", roomID).First(&session).Error
+	if err == nil {
+		return &session, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return nil, errors.New("session_without_participants")
+	}
+
+	session = models.Session{
+		RoomID:    roomID,
+		UserID:    userIDs[0],
+		StartTime: at,
+	}
+	if len(userIDs) > 1 {
+		session.PartnerID = userIDs[1]
+	}
+	if err := s.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Join registra um participante, criando a sessão se ele for o primeiro
+func (s *SessionService) Join(roomID, userID string, at time.Time) (*models.Session, error) {
+	session, err := s.Start(roomID, at, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID && session.PartnerID == "" {
+		session.PartnerID = userID
+		if err := s.DB.Model(session).Update("partner_id", userID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// End fecha a sessão da sala; sessões já encerradas não são alteradas
+func (s *SessionService) End(roomID string, at time.Time) error {
+	return s.DB.Model(&models.Session{}).
+		Where("room_id = ? AND end_time IS NULL", roomID).
+		Update("end_time", at).Error
+}
+
+// Shared diz se os dois usuários estiveram numa mesma sessão iniciada depois de since
+func (s *SessionService) Shared(a, b string, since time.Time) (bool, error) {
+	var count int64
+	err := s.DB.Model(&models.Session{}).
+		Where("((user_id = ? AND partner_id = ?) OR (user_id = ? AND partner_id = ?)) AND start_time > ?", a, b, b, a, since).
+		Count(&count).Error
+	return count > 0, err
+}
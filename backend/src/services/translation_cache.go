@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultTranslationCacheTTL  = 24 * time.Hour
+	defaultTranslationCacheSize = 100000
+	// Textos longos quase nunca se repetem; não vale ocupar o cache com eles
+	maxCachedTextLen = 500
+
+	translationCacheIndex  = "translation:cache:index"
+	translationCacheHits   = "translation:cache:hits"
+	translationCacheMisses = "translation:cache:misses"
+)
+
+// modelVersioned é implementado por provedores cuja saída depende da versão do
+// modelo; trocar o modelo invalida o cache naturalmente
+type modelVersioned interface {
+	ModelVersion() string
+}
+
+// CachedTranslator é um cache read-through no Redis na frente de outro Translator.
+// As entradas expiram após TTL e, acima de MaxEntries, as mais antigas são removidas.
+type CachedTranslator struct {
+	Next       Translator
+	Redis      *redis.Client
+	TTL        time.Duration
+	MaxEntries int64
+}
+
+// TranslationCacheStats é exposto no /health
+type TranslationCacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int64 `json:"entries"`
+}
+
+func (c *CachedTranslator) Name() string { return c.Next.Name() }
+
+func (c *CachedTranslator) Breakers() map[string]BreakerState { return TranslatorBreakers(c.Next) }
+
+func (c *CachedTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	if req.NoCache || len(req.Text) > maxCachedTextLen {
+		return c.Next.Translate(ctx, req)
+	}
+
+	key := c.key(req)
+	if cached := c.lookup(ctx, key); cached != nil {
+		return cached, nil
+	}
+
+	result, err := c.Next.Translate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store(ctx, key, result); err != nil {
+		log.Printf("⚠️ Translation cache write failed: %v", err)
+	}
+	return result, nil
+}
+
+// lookup lê a entrada e atualiza os contadores de hit/miss
+func (c *CachedTranslator) lookup(ctx context.Context, key string) *Translation {
+	if data, err := c.Redis.Get(ctx, key).Bytes(); err == nil {
+		var cached Translation
+		if json.Unmarshal(data, &cached) == nil {
+			c.Redis.Incr(ctx, translationCacheHits)
+			cached.Cached = true
+			cached.Tokens = 0
+			return &cached
+		}
+	}
+	c.Redis.Incr(ctx, translationCacheMisses)
+	return nil
+}
+
+func (c *CachedTranslator) Stats(ctx context.Context) (*TranslationCacheStats, error) {
+	pipe := c.Redis.Pipeline()
+	hits := pipe.Get(ctx, translationCacheHits)
+	misses := pipe.Get(ctx, translationCacheMisses)
+	entries := pipe.ZCard(ctx, translationCacheIndex)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	stats := &TranslationCacheStats{Entries: entries.Val()}
+	stats.Hits, _ = hits.Int64()
+	stats.Misses, _ = misses.Int64()
+	return stats, nil
+}
+
+func (c *CachedTranslator) store(ctx context.Context, key string, t *Translation) error {
+	val, _ := json.Marshal(t)
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = defaultTranslationCacheTTL
+	}
+
+	pipe := c.Redis.TxPipeline()
+	pipe.Set(ctx, key, val, ttl)
+	pipe.ZAdd(ctx, translationCacheIndex, redis.Z{Score: float64(time.Now().UnixMilli()), Member: key})
+	size := pipe.ZCard(ctx, translationCacheIndex)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	max := c.MaxEntries
+	if max <= 0 {
+		max = defaultTranslationCacheSize
+	}
+	over := size.Val() - max
+	if over <= 0 {
+		return nil
+	}
+	// Remove as entradas mais antigas (as já expiradas também saem do índice aqui)
+	evicted, err := c.Redis.ZPopMin(ctx, translationCacheIndex, over).Result()
+	if err != nil || len(evicted) == 0 {
+		return err
+	}
+	keys := make([]string, len(evicted))
+	for i, z := range evicted {
+		keys[i] = z.Member.(string)
+	}
+	return c.Redis.Del(ctx, keys...).Err()
+}
+
+// key combina texto normalizado, par de idiomas e versão do provedor. O
+// contexto da sala fica de fora: as frases curtas e repetidas que o cache
+// atende raramente dependem dele, e incluí-lo zeraria a taxa de acerto.
+func (c *CachedTranslator) key(req TranslationRequest) string {
+	version := c.Next.Name()
+	if v, ok := c.Next.(modelVersioned); ok {
+		version = v.ModelVersion()
+	}
+
+	h := sha256.New()
+	for _, part := range []string{normalizeCacheText(req.Text), req.Source, req.Target, version} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "translation:cache:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeCacheText junta espaços repetidos; maiúsculas e pontuação são
+// preservadas porque mudam a tradução
+func normalizeCacheText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultContextTokens é o orçamento padrão da janela de contexto por sala
+	DefaultContextTokens = 400
+	maxContextMessages   = 12
+)
+
+// ContextMessage é uma mensagem anterior da conversa, enviada ao provedor só
+// como referência (nomes, pronomes, concordância), nunca para ser traduzida
+type ContextMessage struct {
+	Speaker string
+	Text    string
+}
+
+// ConversationWindow guarda as últimas mensagens de uma sala dentro de um
+// orçamento de tokens, descartando as mais antigas. Fica só em memória; quem
+// usa cuida da sincronização.
+type ConversationWindow struct {
+	MaxTokens int
+
+	messages []ContextMessage
+	tokens   int
+}
+
+func NewConversationWindow(maxTokens int) *ConversationWindow {
+	return &ConversationWindow{MaxTokens: maxTokens}
+}
+
+func (w *ConversationWindow) Add(speaker, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	w.messages = append(w.messages, ContextMessage{Speaker: speaker, Text: text})
+	w.tokens += EstimateTokens(text)
+
+	for len(w.messages) > 0 && (w.tokens > w.MaxTokens || len(w.messages) > maxContextMessages) {
+		w.tokens -= EstimateTokens(w.messages[0].Text)
+		w.messages = w.messages[1:]
+	}
+}
+
+// Messages devolve uma cópia, da mais antiga para a mais nova
+func (w *ConversationWindow) Messages() []ContextMessage {
+	if len(w.messages) == 0 {
+		return nil
+	}
+	out := make([]ContextMessage, len(w.messages))
+	copy(out, w.messages)
+	return out
+}
+
+// Tokens é a estimativa atual de tokens na janela
+func (w *ConversationWindow) Tokens() int { return w.tokens }
+
+// EstimateTokens aproxima a contagem de tokens dos modelos (~4 caracteres por token)
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
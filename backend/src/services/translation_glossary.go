@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryTerm é um termo do glossário da sala: sem Translation é mantido
+// como está; com Translation é sempre traduzido assim. Source/Target vazios
+// valem para qualquer idioma.
+type GlossaryTerm struct {
+	Term        string
+	Translation string
+	Source      string
+	Target      string
+
+	// pattern é a busca do termo, compilada uma vez por glossário carregado;
+	// termos montados à mão são compilados na hora
+	pattern *regexp.Regexp
+}
+
+// compiled devolve o termo com a busca já compilada
+func (t GlossaryTerm) compiled() GlossaryTerm {
+	if t.pattern == nil && t.Term != "" {
+		t.pattern = regexp.MustCompile(`(?i)` + regexp.QuoteMeta(t.Term))
+	}
+	return t
+}
+
+// GlossaryTranslator troca os termos do glossário por marcadores ({{0}},
+// {{1}}...) antes de chamar o provedor e os repõe na tradução, então nenhum
+// provedor precisa entender glossários. Fica por fora do cache: o texto com
+// marcadores é o que vira chave, e a reposição acontece depois.
+type GlossaryTranslator struct {
+	Next Translator
+}
+
+var glossaryPlaceholder = regexp.MustCompile(`\{\{\s*(\d+)\s*\}\}`)
+
+func (g *GlossaryTranslator) Name() string { return g.Next.Name() }
+
+func (g *GlossaryTranslator) Breakers() map[string]BreakerState { return TranslatorBreakers(g.Next) }
+
+func (g *GlossaryTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	text, slots := protectGlossaryTerms(req)
+	if len(slots) == 0 {
+		return g.Next.Translate(ctx, req)
+	}
+
+	req.Text = text
+	result, err := g.Next.Translate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := *result
+	out.Text = restoreGlossaryTerms(result.Text, slots)
+	return &out, nil
+}
+
+// TranslateStream segura o fim de cada trecho enquanto houver um marcador
+// incompleto, para o cliente nunca ver "{{1" pela metade
+func (g *GlossaryTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	text, slots := protectGlossaryTerms(req)
+	if len(slots) == 0 {
+		return TranslateStream(ctx, g.Next, req, onDelta)
+	}
+
+	req.Text = text
+	var pending string
+	result, err := TranslateStream(ctx, g.Next, req, func(delta string) {
+		pending += delta
+		cut := len(pending)
+		if i := strings.LastIndex(pending, "{{"); i >= 0 && !strings.Contains(pending[i:], "}}") {
+			cut = i
+		} else if strings.HasSuffix(pending, "{") {
+			cut--
+		}
+		if cut > 0 {
+			onDelta(restoreGlossaryTerms(pending[:cut], slots))
+			pending = pending[cut:]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pending != "" {
+		onDelta(restoreGlossaryTerms(pending, slots))
+	}
+	out := *result
+	out.Text = restoreGlossaryTerms(result.Text, slots)
+	return &out, nil
+}
+
+func (t GlossaryTerm) appliesTo(source, target string) bool {
+	if t.Target != "" && !SameLanguage(t.Target, target) {
+		return false
+	}
+	// Sem origem conhecida o termo vale: ele foi encontrado no texto
+	if t.Source != "" && source != "" && source != "auto" && !SameLanguage(t.Source, source) {
+		return false
+	}
+	return true
+}
+
+// protectGlossaryTerms troca cada ocorrência (palavra inteira, sem diferenciar
+// maiúsculas) por um marcador e devolve o que repor em cada um; termos mais
+// longos têm prioridade ("New York City" antes de "New York"). Marcadores que o
+// próprio usuário digitou também viram marcadores, repostos literalmente.
+func protectGlossaryTerms(req TranslationRequest) (string, []string) {
+	source := req.Detected
+	if source == "" {
+		source = req.Source
+	}
+	var terms []GlossaryTerm
+	for _, t := range req.Glossary {
+		if t.Term != "" && t.appliesTo(source, req.Target) {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return req.Text, nil
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return utf8.RuneCountInString(terms[i].Term) > utf8.RuneCountInString(terms[j].Term)
+	})
+
+	type span struct {
+		start, end int
+		value      string
+	}
+	var spans []span
+	taken := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	// Escapa o que já parece marcador, senão a reposição trocaria o texto do usuário
+	for _, m := range glossaryPlaceholder.FindAllStringIndex(req.Text, -1) {
+		spans = append(spans, span{m[0], m[1], req.Text[m[0]:m[1]]})
+	}
+	typed := len(spans)
+	for _, t := range terms {
+		for _, m := range t.compiled().pattern.FindAllStringIndex(req.Text, -1) {
+			if !wordBoundary(req.Text, m[0], m[1]) || taken(m[0], m[1]) {
+				continue
+			}
+			value := t.Translation
+			if value == "" {
+				// "Não traduzir" preserva o que o usuário digitou
+				value = req.Text[m[0]:m[1]]
+			}
+			spans = append(spans, span{m[0], m[1], value})
+		}
+	}
+	if len(spans) == typed {
+		return req.Text, nil
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	slots := make([]string, len(spans))
+	last := 0
+	for i, s := range spans {
+		b.WriteString(req.Text[last:s.start])
+		b.WriteString("{{" + strconv.Itoa(i) + "}}")
+		slots[i] = s.value
+		last = s.end
+	}
+	b.WriteString(req.Text[last:])
+	return b.String(), slots
+}
+
+func restoreGlossaryTerms(text string, slots []string) string {
+	return glossaryPlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+		i, err := strconv.Atoi(glossaryPlaceholder.FindStringSubmatch(m)[1])
+		if err != nil || i >= len(slots) {
+			return m
+		}
+		return slots[i]
+	})
+}
+
+// wordBoundary confere que a ocorrência não está no meio de outra palavra
+func wordBoundary(text string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); isWord(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if r, _ := utf8.DecodeRuneInString(text[end:]); isWord(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultTranslationTimeout = 5 * time.Second
+	defaultTranslationRetries = 2
+	defaultRetryBackoff       = 200 * time.Millisecond
+	defaultBreakerThreshold   = 5
+	defaultBreakerCooldown    = 30 * time.Second
+)
+
+var ErrCircuitOpen = errors.New("translation_circuit_open")
+
+// Estados do circuit breaker
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// CircuitBreaker abre após Threshold falhas seguidas e, passado o Cooldown,
+// deixa uma única chamada de teste passar (half_open) antes de fechar de novo
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerState é o retrato exportado no /health
+type BreakerState struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.current() {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown() {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if b.current() == BreakerHalfOpen || b.failures >= threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release libera a chamada de teste sem contar sucesso nem falha
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerState{State: b.current(), Failures: b.failures}
+	if s.State != BreakerClosed {
+		opened := b.openedAt
+		s.OpenedAt = &opened
+	}
+	return s
+}
+
+func (b *CircuitBreaker) current() string {
+	if b.state == "" {
+		return BreakerClosed
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown > 0 {
+		return b.Cooldown
+	}
+	return defaultBreakerCooldown
+}
+
+// ResilientTranslator dá a cada tentativa um prazo próprio, repete erros
+// transitórios com backoff exponencial e jitter, e passa pelo circuit breaker
+type ResilientTranslator struct {
+	Next    Translator
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+	Breaker *CircuitBreaker
+}
+
+func (r *ResilientTranslator) Name() string { return r.Next.Name() }
+
+func (r *ResilientTranslator) ModelVersion() string {
+	if v, ok := r.Next.(modelVersioned); ok {
+		return v.ModelVersion()
+	}
+	return r.Next.Name()
+}
+
+func (r *ResilientTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	return r.run(ctx, req, nil)
+}
+
+// run executa as tentativas; com onDelta usa streaming e só repete enquanto
+// nada tiver sido emitido
+func (r *ResilientTranslator) run(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	streamed := false
+	return resilientCall(ctx, r, func(ctx context.Context) (*Translation, error) {
+		if onDelta == nil {
+			return r.Next.Translate(ctx, req)
+		}
+		return TranslateStream(ctx, r.Next, req, func(delta string) {
+			streamed = true
+			onDelta(delta)
+		})
+	}, func() bool { return streamed })
+}
+
+// Correct passa a revisão do coach pelo mesmo breaker e pelas mesmas tentativas
+func (r *ResilientTranslator) Correct(ctx context.Context, req CoachRequest) (*Correction, error) {
+	coach, ok := r.Next.(Coach)
+	if !ok {
+		return nil, ErrNoCoach
+	}
+	return resilientCall(ctx, r, func(ctx context.Context) (*Correction, error) {
+		return coach.Correct(ctx, req)
+	}, nil)
+}
+
+// resilientCall chama fn com prazo por tentativa e repete erros transitórios;
+// committed diz se a tentativa já entregou algo e não pode mais ser repetida
+func resilientCall[T any](ctx context.Context, r *ResilientTranslator, fn func(context.Context) (T, error), committed func() bool) (T, error) {
+	var zero T
+	if err := r.Breaker.Allow(); err != nil {
+		return zero, err
+	}
+
+	var err error
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleepWithJitter(ctx, r.backoff(), attempt); waitErr != nil {
+				break
+			}
+		}
+
+		var result T
+		result, err = callWithTimeout(ctx, r.timeout(), fn)
+		if err == nil {
+			r.Breaker.Success()
+			return result, nil
+		}
+		if (committed != nil && committed()) || ctx.Err() != nil || !retryableTranslationError(err) {
+			break
+		}
+	}
+
+	// Cancelamento de quem chamou não diz nada sobre a saúde do provedor
+	if ctx.Err() == nil {
+		r.Breaker.Failure()
+	} else {
+		r.Breaker.Release()
+	}
+	return zero, err
+}
+
+// callWithTimeout dá a uma tentativa o próprio prazo
+func callWithTimeout[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func (r *ResilientTranslator) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return defaultTranslationTimeout
+}
+
+func (r *ResilientTranslator) backoff() time.Duration {
+	if r.Backoff > 0 {
+		return r.Backoff
+	}
+	return defaultRetryBackoff
+}
+
+// Breakers implementa breakerReporter
+func (r *ResilientTranslator) Breakers() map[string]BreakerState {
+	return map[string]BreakerState{r.Name(): r.Breaker.State()}
+}
+
+// sleepWithJitter espera um valor aleatório em [base*2^(n-1)/2, base*2^(n-1)]
+func sleepWithJitter(ctx context.Context, base time.Duration, attempt int) error {
+	max := base << (attempt - 1)
+	wait := max/2 + time.Duration(rand.Int63n(int64(max/2)+1))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryableTranslationError separa falhas transitórias (timeout, rede, 429/5xx,
+// gRPC Unavailable/ResourceExhausted) de erros que se repetiriam igual
+func retryableTranslationError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Internal, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// breakerReporter é implementado pelos Translators que têm (ou envolvem) circuit breakers
+type breakerReporter interface {
+	Breakers() map[string]BreakerState
+}
+
+// TranslatorBreakers coleta o estado dos circuit breakers de toda a cadeia de tradução
+func TranslatorBreakers(t Translator) map[string]BreakerState {
+	if r, ok := t.(breakerReporter); ok {
+		return r.Breakers()
+	}
+	return map[string]BreakerState{}
+}
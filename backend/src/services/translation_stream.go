@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"strings"
+)
+
+// StreamingTranslator é implementado pelos provedores que entregam a tradução
+// em pedaços; onDelta recebe cada trecho novo, na ordem
+type StreamingTranslator interface {
+	Translator
+	TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error)
+}
+
+// TranslateStream usa streaming quando t suporta; caso contrário entrega a
+// tradução inteira como um único delta
+func TranslateStream(ctx context.Context, t Translator, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	if s, ok := t.(StreamingTranslator); ok {
+		return s.TranslateStream(ctx, req, onDelta)
+	}
+	result, err := t.Translate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	onDelta(result.Text)
+	return result, nil
+}
+
+func (c *ChainTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	// Depois que um provedor começou a emitir não dá para trocar de provedor
+	streamed := false
+	emit := func(delta string) {
+		streamed = true
+		onDelta(delta)
+	}
+
+	var lastErr error = ErrNoTranslator
+	for _, t := range c.Translators {
+		result, err := TranslateStream(ctx, t, req, emit)
+		if err == nil {
+			return result, nil
+		}
+		if streamed || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *CachedTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	if req.NoCache || len(req.Text) > maxCachedTextLen {
+		return TranslateStream(ctx, c.Next, req, onDelta)
+	}
+
+	key := c.key(req)
+	if cached := c.lookup(ctx, key); cached != nil {
+		onDelta(cached.Text)
+		return cached, nil
+	}
+
+	result, err := TranslateStream(ctx, c.Next, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	c.store(ctx, key, result)
+	return result, nil
+}
+
+func (r *ResilientTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	return r.run(ctx, req, onDelta)
+}
+
+// TranslateStream emite palavra por palavra
+func (f *FakeTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	result, err := f.Translate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, word := range strings.Fields(result.Text) {
+		if i > 0 {
+			word = " " + word
+		}
+		onDelta(word)
+	}
+	return result, nil
+}
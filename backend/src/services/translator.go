@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TranslationRequest é o pedido comum a todos os provedores
+type TranslationRequest struct {
+	Text   string
+	Source string // idioma declarado de quem escreveu (dica) ou "auto"
+	Target string
+	// Detected é o idioma identificado com confiança antes da tradução; vazio
+	// deixa a detecção a cargo do provedor
+	Detected string
+	UserID   string
+
+	// Context traz as mensagens recentes da sala para manter nomes, pronomes e
+	// concordância consistentes; provedores sem suporte a contexto o ignoram
+	Context []ContextMessage
+
+	// Glossary são os termos da sala que não devem ser traduzidos ou têm
+	// tradução fixa; aplicados pelo GlossaryTranslator
+	Glossary []GlossaryTerm
+
+	// NoCache impede que mensagens sensíveis sejam gravadas no cache de tradução
+	NoCache bool
+}
+
+type Translation struct {
+	Text           string `json:"text"`
+	SourceLanguage string `json:"source_language,omitempty"`
+	Provider       string `json:"provider"`
+	Cached         bool   `json:"cached,omitempty"`
+	// Tokens cobrados pelo provedor (0 quando ele não informa)
+	Tokens int `json:"tokens,omitempty"`
+}
+
+// Translator é implementado por cada provedor de tradução (Gemini, HTTP, fake)
+// e pelos decoradores que os envolvem
+type Translator interface {
+	Name() string
+	Translate(ctx context.Context, req TranslationRequest) (*Translation, error)
+}
+
+var ErrNoTranslator = errors.New("no_translation_provider")
+
+// NewTranslator monta o provedor a partir de TRANSLATION_PROVIDER, que aceita
+// um nome (gemini, libretranslate, fake) ou uma lista separada por vírgulas,
+// usada como cadeia de fallback na ordem dada. Sem a variável, usa os
+// provedores que estiverem configurados; sem nenhum, retorna nil.
+func NewTranslator() Translator {
+	names := splitList(os.Getenv("TRANSLATION_PROVIDER"))
+	if len(names) == 0 {
+		if os.Getenv("GEMINI_API_KEY") != "" {
+			names = append(names, "gemini")
+		}
+		if os.Getenv("LIBRETRANSLATE_URL") != "" {
+			names = append(names, "libretranslate")
+		}
+	}
+
+	// Cada provedor tem o próprio breaker, então a cadeia pula só o que está fora
+	var chain []Translator
+	for _, name := range names {
+		t, err := newProvider(strings.ToLower(name))
+		if err != nil {
+			log.Printf("⚠️ Translation provider %s disabled: %v", name, err)
+			continue
+		}
+		chain = append(chain, newResilientTranslator(t))
+	}
+
+	switch len(chain) {
+	case 0:
+		log.Println("⚠️ No translation provider configured. Messages will be delivered untranslated.")
+		return nil
+	case 1:
+		log.Printf("🌐 Translation provider: %s", chain[0].Name())
+		return chain[0]
+	}
+	t := &ChainTranslator{Translators: chain}
+	log.Printf("🌐 Translation provider: %s", t.Name())
+	return t
+}
+
+// newResilientTranslator envolve o provedor com as tentativas e o breaker
+// configurados em TRANSLATION_TIMEOUT, TRANSLATION_RETRIES e TRANSLATION_BREAKER_*
+func newResilientTranslator(t Translator) *ResilientTranslator {
+	timeout, _ := time.ParseDuration(os.Getenv("TRANSLATION_TIMEOUT"))
+	retries := defaultTranslationRetries
+	if v, err := strconv.Atoi(os.Getenv("TRANSLATION_RETRIES")); err == nil && v >= 0 {
+		retries = v
+	}
+	threshold, _ := strconv.Atoi(os.Getenv("TRANSLATION_BREAKER_THRESHOLD"))
+	cooldown, _ := time.ParseDuration(os.Getenv("TRANSLATION_BREAKER_COOLDOWN"))
+	return &ResilientTranslator{
+		Next:    t,
+		Timeout: timeout,
+		Retries: retries,
+		Breaker: &CircuitBreaker{Threshold: threshold, Cooldown: cooldown},
+	}
+}
+
+func newProvider(name string) (Translator, error) {
+	switch name {
+	case "gemini":
+		key := os.Getenv("GEMINI_API_KEY")
+		if key == "" {
+			return nil, errors.New("GEMINI_API_KEY not set")
+		}
+		return NewGeminiTranslator(key, os.Getenv("GEMINI_MODEL"))
+	case "libretranslate":
+		url := os.Getenv("LIBRETRANSLATE_URL")
+		if url == "" {
+			return nil, errors.New("LIBRETRANSLATE_URL not set")
+		}
+		return NewHTTPTranslator(url, os.Getenv("LIBRETRANSLATE_API_KEY")), nil
+	case "fake":
+		return &FakeTranslator{}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q", name)
+}
+
+// ChainTranslator tenta cada provedor em ordem e devolve a primeira tradução
+type ChainTranslator struct {
+	Translators []Translator
+}
+
+func (c *ChainTranslator) Name() string {
+	names := make([]string, len(c.Translators))
+	for i, t := range c.Translators {
+		names[i] = t.Name()
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+// ModelVersion combina as versões de todos os provedores da cadeia
+func (c *ChainTranslator) ModelVersion() string {
+	versions := make([]string, len(c.Translators))
+	for i, t := range c.Translators {
+		versions[i] = t.Name()
+		if v, ok := t.(modelVersioned); ok {
+			versions[i] = v.ModelVersion()
+		}
+	}
+	return strings.Join(versions, ",")
+}
+
+func (c *ChainTranslator) Breakers() map[string]BreakerState {
+	states := make(map[string]BreakerState)
+	for _, t := range c.Translators {
+		for name, state := range TranslatorBreakers(t) {
+			states[name] = state
+		}
+	}
+	return states
+}
+
+func (c *ChainTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	errs := []error{ErrNoTranslator}
+	for _, t := range c.Translators {
+		result, err := t.Translate(ctx, req)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("⚠️ Translator %s failed, trying next: %v", t.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", t.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// FakeTranslator é determinístico e não sai da máquina: prefixa o idioma alvo e
+// troca palavras inteiras de Words, o bastante para testes verem o texto mudar
+type FakeTranslator struct {
+	Words map[string]string
+}
+
+func (f *FakeTranslator) Name() string { return "fake" }
+
+func (f *FakeTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	words := strings.Fields(req.Text)
+	for i, w := range words {
+		if t, ok := f.Words[w]; ok {
+			words[i] = t
+		}
+	}
+
+	source := req.Source
+	if source == "" || source == "auto" {
+		source = "und"
+	}
+	return &Translation{
+		Text:           "[" + req.Target + "] " + strings.Join(words, " "),
+		SourceLanguage: source,
+		Provider:       f.Name(),
+	}, nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTURNTTL = time.Hour
+
+var (
+	ErrTURNNotConfigured = errors.New("turn_not_configured")
+	ErrTURNRegionEmpty   = errors.New("turn_region_without_servers")
+)
+
+// TURNConfig descreve os servidores ICE por região. Quando carregada de arquivo
+// (TURN_CONFIG_FILE) é relida sempre que o arquivo muda, permitindo rotacionar
+// o segredo sem reiniciar o processo.
+type TURNConfig struct {
+	Secret        string                `json:"secret"`
+	TTLSeconds    int                   `json:"ttl_seconds"`
+	DefaultRegion string                `json:"default_region"`
+	Regions       map[string]TURNRegion `json:"regions"`
+}
+
+// TURNRegion aceita URLs explícitas ou apenas o host, caso em que geramos
+// STUN + TURN sobre UDP/TCP/TLS nas portas padrão do coturn.
+type TURNRegion struct {
+	Host string   `json:"host"`
+	STUN []string `json:"stun"`
+	TURN []string `json:"turn"`
+}
+
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+type ICEServersResponse struct {
+	ICEServers []ICEServer `json:"iceServers"`
+	Region     string      `json:"region"`
+	TTL        int         `json:"ttl"`
+	ExpiresAt  int64       `json:"expires_at"`
+}
+
+type TURNService struct {
+	ConfigPath string
+
+	mu      sync.RWMutex
+	config  *TURNConfig
+	modTime time.Time
+}
+
+func NewTURNService() *TURNService {
+	s := &TURNService{ConfigPath: os.Getenv("TURN_CONFIG_FILE")}
+	if s.ConfigPath != "" {
+		if _, err := s.currentConfig(); err != nil {
+			log.Printf("⚠️ TURN config not loaded: %v", err)
+		}
+		return s
+	}
+
+	// Fallback para as variáveis documentadas em docs/COTURN-SETUP.md
+	secret := os.Getenv("TURN_SECRET")
+	turnURLs := splitList(os.Getenv("TURN_URLS"))
+	if secret == "" || len(turnURLs) == 0 {
+		log.Println("⚠️ TURN_SECRET/TURN_URLS not found. TURN credentials will be disabled.")
+		return s
+	}
+	s.config = &TURNConfig{
+		Secret:        secret,
+		DefaultRegion: "default",
+		Regions: map[string]TURNRegion{
+			"default": {STUN: splitList(os.Getenv("STUN_URLS")), TURN: turnURLs},
+		},
+	}
+	return s
+}
+
+// ICEServers gera credenciais efêmeras no esquema use-auth-secret do coturn:
+// username = "<expiração unix>:<userID>", credential = base64(HMAC-SHA1(secret, username)).
+func (s *TURNService) ICEServers(userID, region string) (*ICEServersResponse, error) {
+	cfg, err := s.currentConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name, reg := cfg.region(region)
+	ttl := defaultTURNTTL
+	if cfg.TTLSeconds > 0 {
+		ttl = time.Duration(cfg.TTLSeconds) * time.Second
+	}
+	expiresAt := time.Now().Add(ttl).Unix()
+
+	username := fmt.Sprintf("%d:%s", expiresAt, userID)
+	mac := hmac.New(sha1.New, []byte(cfg.Secret))
+	mac.Write([]byte(username))
+	credential := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	stun, turn := reg.urls()
+	var servers []ICEServer
+	if len(stun) > 0 {
+		servers = append(servers, ICEServer{URLs: stun})
+	}
+	servers = append(servers, ICEServer{URLs: turn, Username: username, Credential: credential})
+
+	return &ICEServersResponse{
+		ICEServers: servers,
+		Region:     name,
+		TTL:        int(ttl.Seconds()),
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// currentConfig relê o arquivo de configuração quando a data de modificação muda
+func (s *TURNService) currentConfig() (*TURNConfig, error) {
+	if s.ConfigPath == "" {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.config == nil {
+			return nil, ErrTURNNotConfigured
+		}
+		return s.config, nil
+	}
+
+	info, err := os.Stat(s.ConfigPath)
+	if err != nil {
+		return s.lastConfig(err)
+	}
+
+	s.mu.RLock()
+	cfg, fresh := s.config, info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if cfg != nil && fresh {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(s.ConfigPath)
+	if err != nil {
+		return s.lastConfig(err)
+	}
+	var next TURNConfig
+	if err := json.Unmarshal(data, &next); err != nil {
+		return s.lastConfig(err)
+	}
+	if err := next.validate(); err != nil {
+		return s.lastConfig(err)
+	}
+
+	s.mu.Lock()
+	s.config = &next
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	log.Printf("🧊 TURN config loaded (%d regions)", len(next.Regions))
+	return &next, nil
+}
+
+// lastConfig mantém a última configuração válida se a releitura falhar
+func (s *TURNService) lastConfig(err error) (*TURNConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config == nil {
+		return nil, err
+	}
+	log.Printf("⚠️ TURN config reload failed, keeping previous: %v", err)
+	return s.config, nil
+}
+
+// validate exige o segredo e que toda região gere ao menos uma URL TURN
+// (host ou lista explícita); sem isso o ICEServer sairia com urls vazio
+func (c *TURNConfig) validate() error {
+	if c.Secret == "" || len(c.Regions) == 0 {
+		return ErrTURNNotConfigured
+	}
+	for name, reg := range c.Regions {
+		if _, turn := reg.urls(); len(turn) == 0 {
+			return fmt.Errorf("%w: %s", ErrTURNRegionEmpty, name)
+		}
+	}
+	return nil
+}
+
+func (c *TURNConfig) region(name string) (string, TURNRegion) {
+	if reg, ok := c.Regions[name]; ok {
+		return name, reg
+	}
+	if reg, ok := c.Regions[c.DefaultRegion]; ok {
+		return c.DefaultRegion, reg
+	}
+	for n, reg := range c.Regions {
+		return n, reg
+	}
+	return "", TURNRegion{}
+}
+
+func (r TURNRegion) urls() (stun, turn []string) {
+	stun, turn = r.STUN, r.TURN
+	if r.Host == "" {
+		return stun, turn
+	}
+	if len(stun) == 0 {
+		stun = []string{"stun:" + r.Host + ":3478"}
+	}
+	if len(turn) == 0 {
+		turn = []string{
+			"turn:" + r.Host + ":3478?transport=udp",
+			"turn:" + r.Host + ":3478?transport=tcp",
+			"turns:" + r.Host + ":5349?transport=tcp",
+		}
+	}
+	return stun, turn
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultTier    = "free"
+	usageRetention = 35 * 24 * time.Hour
+	usageDayLayout = "2006-01-02"
+	// Mudanças de plano valem em até defaultTierCacheTTL
+	defaultTierCacheTTL = 10 * time.Minute
+)
+
+// Cotas diárias padrão, em tokens; 0 é ilimitado
+var defaultTierQuotas = map[string]int64{
+	"free": 50000,
+	"pro":  500000,
+}
+
+var ErrQuotaExceeded = errors.New("quota_exceeded")
+
+const (
+	QuotaScopeUser   = "user"
+	QuotaScopeGlobal = "global"
+)
+
+// QuotaError diz qual cota estourou; errors.Is(err, ErrQuotaExceeded) vale para as duas
+type QuotaError struct {
+	Scope    string
+	Tier     string
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string { return "quota_exceeded (" + e.Scope + ")" }
+
+func (e *QuotaError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+// TierSource informa o plano do usuário, que define a cota diária
+type TierSource interface {
+	Tier(userID string) (string, error)
+}
+
+// UsageTotals é o consumo acumulado num dia
+type UsageTotals struct {
+	Characters int64 `json:"characters"`
+	Tokens     int64 `json:"tokens"`
+	Requests   int64 `json:"requests"`
+}
+
+type UserUsage struct {
+	UserID string `json:"user_id"`
+	UsageTotals
+}
+
+// UsageReport é a resposta de GET /v1/admin/usage
+type UsageReport struct {
+	Date          string           `json:"date"`
+	Global        UsageTotals      `json:"global"`
+	GlobalCeiling int64            `json:"global_ceiling"`
+	EstimatedCost float64          `json:"estimated_cost,omitempty"`
+	TierQuotas    map[string]int64 `json:"tier_quotas"`
+	TopUsers      []UserUsage      `json:"top_users"`
+}
+
+// UsageService contabiliza caracteres e tokens de tradução por usuário e por
+// dia (UTC) no Redis e aplica as cotas. A checagem acontece antes da chamada e
+// o registro depois, então a cota é um limite suave: chamadas simultâneas
+// podem passar um pouco dele.
+//
+// Layout no Redis (expira depois de 35 dias):
+//
+//	usage:<dia>:user:<userID>  HASH  characters, tokens, requests
+//	usage:<dia>:global         HASH  idem, somando todos
+//	usage:<dia>:ranking        ZSET  userID, score = tokens
+//	usage:tier:<userID>        STRING  plano do usuário, em cache por TierCacheTTL
+type UsageService struct {
+	Redis *redis.Client
+	Users TierSource
+
+	// Cota diária em tokens por plano; planos desconhecidos usam a de DefaultTier
+	TierQuotas map[string]int64
+	// Teto global diário em tokens (0 desativa)
+	GlobalDailyTokens int64
+	// Preço por 1000 tokens, só para estimar o gasto no relatório
+	PricePer1KTokens float64
+	// Por quanto tempo o plano lido de Users fica no Redis (0 usa o padrão)
+	TierCacheTTL time.Duration
+}
+
+// NewUsageService lê QUOTA_TIERS ("free=50000,pro=500000"),
+// QUOTA_GLOBAL_DAILY_TOKENS, QUOTA_TIER_CACHE_TTL e TRANSLATION_PRICE_PER_1K_TOKENS
+func NewUsageService(rdb *redis.Client, users TierSource) *UsageService {
+	s := &UsageService{Redis: rdb, Users: users, TierQuotas: make(map[string]int64)}
+	for tier, quota := range defaultTierQuotas {
+		s.TierQuotas[tier] = quota
+	}
+	for _, item := range splitList(os.Getenv("QUOTA_TIERS")) {
+		tier, value, ok := strings.Cut(item, "=")
+		quota, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if !ok || err != nil || quota < 0 {
+			log.Printf("⚠️ Ignoring invalid QUOTA_TIERS entry %q", item)
+			continue
+		}
+		s.TierQuotas[strings.ToLower(strings.TrimSpace(tier))] = quota
+	}
+	s.GlobalDailyTokens, _ = strconv.ParseInt(os.Getenv("QUOTA_GLOBAL_DAILY_TOKENS"), 10, 64)
+	s.PricePer1KTokens, _ = strconv.ParseFloat(os.Getenv("TRANSLATION_PRICE_PER_1K_TOKENS"), 64)
+	s.TierCacheTTL, _ = time.ParseDuration(os.Getenv("QUOTA_TIER_CACHE_TTL"))
+	return s
+}
+
+// Check retorna um *QuotaError se o usuário ou o total do dia já passaram do limite
+func (s *UsageService) Check(ctx context.Context, userID string) error {
+	now := time.Now().UTC()
+	day := now.Format(usageDayLayout)
+	resetsAt := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	pipe := s.Redis.Pipeline()
+	userTokens := pipe.HGet(ctx, usageUserKey(day, userID), "tokens")
+	globalTokens := pipe.HGet(ctx, usageGlobalKey(day), "tokens")
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+
+	global, _ := globalTokens.Int64()
+	if s.GlobalDailyTokens > 0 && global >= s.GlobalDailyTokens {
+		return &QuotaError{Scope: QuotaScopeGlobal, Limit: s.GlobalDailyTokens, Used: global, ResetsAt: resetsAt}
+	}
+
+	tier := s.tier(ctx, userID)
+	limit := s.quota(tier)
+	used, _ := userTokens.Int64()
+	if limit > 0 && used >= limit {
+		return &QuotaError{Scope: QuotaScopeUser, Tier: tier, Limit: limit, Used: used, ResetsAt: resetsAt}
+	}
+	return nil
+}
+
+// Record soma o consumo de uma chamada ao dia corrente
+func (s *UsageService) Record(ctx context.Context, userID string, characters, tokens int64) error {
+	day := time.Now().UTC().Format(usageDayLayout)
+	userKey, globalKey, rankingKey := usageUserKey(day, userID), usageGlobalKey(day), usageRankingKey(day)
+
+	pipe := s.Redis.TxPipeline()
+	for _, key := range []string{userKey, globalKey} {
+		pipe.HIncrBy(ctx, key, "characters", characters)
+		pipe.HIncrBy(ctx, key, "tokens", tokens)
+		pipe.HIncrBy(ctx, key, "requests", 1)
+	}
+	pipe.ZIncrBy(ctx, rankingKey, float64(tokens), userID)
+	for _, key := range []string{userKey, globalKey, rankingKey} {
+		pipe.Expire(ctx, key, usageRetention)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Report resume um dia (UTC, "2006-01-02"; vazio = hoje) com os maiores consumidores
+func (s *UsageService) Report(ctx context.Context, day string, limit int64) (*UsageReport, error) {
+	if day == "" {
+		day = time.Now().UTC().Format(usageDayLayout)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	global, err := s.totals(ctx, usageGlobalKey(day))
+	if err != nil {
+		return nil, err
+	}
+	report := &UsageReport{
+		Date:          day,
+		Global:        global,
+		GlobalCeiling: s.GlobalDailyTokens,
+		TierQuotas:    s.TierQuotas,
+		TopUsers:      []UserUsage{},
+	}
+	if s.PricePer1KTokens > 0 {
+		report.EstimatedCost = float64(global.Tokens) / 1000 * s.PricePer1KTokens
+	}
+
+	ids, err := s.Redis.ZRevRange(ctx, usageRankingKey(day), 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		totals, err := s.totals(ctx, usageUserKey(day, id))
+		if err != nil {
+			return nil, err
+		}
+		report.TopUsers = append(report.TopUsers, UserUsage{UserID: id, UsageTotals: totals})
+	}
+	return report, nil
+}
+
+func (s *UsageService) totals(ctx context.Context, key string) (UsageTotals, error) {
+	values, err := s.Redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return UsageTotals{}, err
+	}
+	var t UsageTotals
+	t.Characters, _ = strconv.ParseInt(values["characters"], 10, 64)
+	t.Tokens, _ = strconv.ParseInt(values["tokens"], 10, 64)
+	t.Requests, _ = strconv.ParseInt(values["requests"], 10, 64)
+	return t, nil
+}
+
+// tier consulta o plano, primeiro no cache do Redis para não ir ao banco a
+// cada tradução; sem fonte ou com erro vale DefaultTier (sem cachear)
+func (s *UsageService) tier(ctx context.Context, userID string) string {
+	if s.Users == nil {
+		return DefaultTier
+	}
+	key := usageTierKey(userID)
+	if tier, err := s.Redis.Get(ctx, key).Result(); err == nil && tier != "" {
+		return tier
+	}
+
+	tier, err := s.Users.Tier(userID)
+	if err != nil {
+		return DefaultTier
+	}
+	if tier == "" {
+		tier = DefaultTier
+	}
+	ttl := s.TierCacheTTL
+	if ttl <= 0 {
+		ttl = defaultTierCacheTTL
+	}
+	if err := s.Redis.Set(ctx, key, tier, ttl).Err(); err != nil {
+		log.Printf("⚠️ Tier not cached for %s: %v", userID, err)
+	}
+	return tier
+}
+
+func (s *UsageService) quota(tier string) int64 {
+	if quota, ok := s.TierQuotas[tier]; ok {
+		return quota
+	}
+	return s.TierQuotas[DefaultTier]
+}
+
+func usageUserKey(day, userID string) string { return "usage:" + day + ":user:" + userID }
+func usageGlobalKey(day string) string       { return "usage:" + day + ":global" }
+func usageRankingKey(day string) string      { return "usage:" + day + ":ranking" }
+func usageTierKey(userID string) string      { return "usage:tier:" + userID }
+
+// MeteredTranslator aplica as cotas antes de traduzir e contabiliza o consumo
+// depois. Acertos de cache contam caracteres, mas não tokens; provedores que
+// não informam tokens são estimados pelo tamanho do texto.
+type MeteredTranslator struct {
+	Next  Translator
+	Usage *UsageService
+}
+
+func (m *MeteredTranslator) Name() string { return m.Next.Name() }
+
+func (m *MeteredTranslator) Breakers() map[string]BreakerState { return TranslatorBreakers(m.Next) }
+
+func (m *MeteredTranslator) Translate(ctx context.Context, req TranslationRequest) (*Translation, error) {
+	if err := m.check(ctx, req); err != nil {
+		return nil, err
+	}
+	result, err := m.Next.Translate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m.record(ctx, req, result)
+	return result, nil
+}
+
+func (m *MeteredTranslator) TranslateStream(ctx context.Context, req TranslationRequest, onDelta func(string)) (*Translation, error) {
+	if err := m.check(ctx, req); err != nil {
+		return nil, err
+	}
+	result, err := TranslateStream(ctx, m.Next, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	m.record(ctx, req, result)
+	return result, nil
+}
+
+// check deixa passar se o Redis falhar: a cota não deve derrubar o chat
+func (m *MeteredTranslator) check(ctx context.Context, req TranslationRequest) error {
+	err := m.Usage.Check(ctx, req.UserID)
+	if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+		log.Printf("⚠️ Quota check failed: %v", err)
+		return nil
+	}
+	return err
+}
+
+func (m *MeteredTranslator) record(ctx context.Context, req TranslationRequest, result *Translation) {
+	tokens := int64(result.Tokens)
+	if tokens == 0 && !result.Cached {
+		tokens = int64(EstimateTokens(req.Text) + EstimateTokens(result.Text))
+	}
+	characters := int64(utf8.RuneCountInString(req.Text))
+	if err := m.Usage.Record(ctx, req.UserID, characters, tokens); err != nil {
+		log.Printf("⚠️ Usage not recorded for %s: %v", req.UserID, err)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestBlockServiceKeepsMatchingInSync(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	bs := &services.BlockService{DB: newTestDB(t), Match: ms}
+	ms.Blocks = bs
+
+	if err := bs.Block("a", "a"); err != services.ErrCannotBlockSelf {
+		t.Errorf("self block = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := bs.Block("a", "b"); err != nil {
+			t.Fatalf("Block: %v", err)
+		}
+	}
+	bs.Block("b", "a")
+	if blocks, _ := bs.List("a"); len(blocks) != 1 || blocks[0].BlockedID != "b" {
+		t.Errorf("a's blocks = %+v", blocks)
+	}
+	if ids, _ := bs.Exclusions("a"); len(ids) == 0 || ids[0] != "b" {
+		t.Errorf("exclusions of a = %v", ids)
+	}
+
+	// Desfazer o bloqueio de a não desfaz o de b
+	if removed, err := bs.Unblock("a", "b"); !removed || err != nil {
+		t.Fatalf("Unblock = %v, %v", removed, err)
+	}
+	if removed, _ := bs.Unblock("a", "b"); removed {
+		t.Error("second unblock removed something")
+	}
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"})
+	if res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"}); res.Status != services.MatchStatusQueued {
+		t.Fatalf("paired while b still blocks a: %+v", res)
+	}
+
+	bs.Unblock("b", "a")
+	res, err := ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"})
+	if err != nil || res.Status != services.MatchStatusMatched || res.Partner.UserID != "a" {
+		t.Errorf("after both unblocked: %+v, %v", res, err)
+	}
+}
+
+func TestUnblockHandlerOnlyRemovesOwnBlocks(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	db := newTestDB(t)
+	bs := &services.BlockService{DB: db, Match: ms}
+	bs.Block("alice", "bob")
+	handler := &controllers.BlockHandler{BlockService: bs}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := func(c *gin.Context) { c.Set("user_id", c.GetHeader("X-User")) }
+	r.GET("/v1/blocks", auth, handler.HandleListBlocks)
+	r.DELETE("/v1/blocks/:id", auth, handler.HandleUnblock)
+	do := func(userID, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// bob não desfaz o bloqueio que alice fez contra ele
+	if w := do("bob", http.MethodDelete, "/v1/blocks/alice"); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "block_not_found") {
+		t.Errorf("unblock by the blocked user: %d %s", w.Code, w.Body)
+	}
+	if w := do("bob", http.MethodGet, "/v1/blocks"); !strings.Contains(w.Body.String(), `"blocks":[]`) {
+		t.Errorf("bob's blocks = %s", w.Body)
+	}
+	if w := do("alice", http.MethodGet, "/v1/blocks"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"blocked_id":"bob"`) {
+		t.Errorf("alice's blocks = %d %s", w.Code, w.Body)
+	}
+
+	if w := do("alice", http.MethodDelete, "/v1/blocks/bob"); w.Code != http.StatusNoContent {
+		t.Errorf("unblock: %d %s", w.Code, w.Body)
+	}
+	var count int64
+	db.Model(&models.Block{}).Count(&count)
+	if count != 0 {
+		t.Errorf("blocks left = %d", count)
+	}
+	if w := do("alice", http.MethodDelete, "/v1/blocks/bob"); w.Code != http.StatusNotFound {
+		t.Errorf("second unblock: %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// newTranslatedGroup abre uma mesa de inglês b1 com um membro por idioma nativo
+func newTranslatedGroup(t *testing.T, translator services.Translator, natives ...string) []*wsClient {
+	t.Helper()
+	return newGroupWithQueries(t, translator, natives, nil)
+}
+
+// newGroupWithQueries é newTranslatedGroup com a query de conexão de cada membro (ex.: "stream=1")
+func newGroupWithQueries(t *testing.T, translator services.Translator, natives, queries []string) []*wsClient {
+	t.Helper()
+	h, ms := newTestWSHandler(t)
+	h.Translator = translator
+	h.GroupService = &services.GroupService{Redis: ms.Redis, Capacity: len(natives)}
+	srv := serveWS(t, h)
+
+	var clients []*wsClient
+	for i, native := range natives {
+		query := ""
+		if i < len(queries) {
+			query = queries[i]
+		}
+		c := dialWS(t, srv, fmt.Sprintf("u%d-%s", i, native), query)
+		c.send("join_group", map[string]string{"target_lang": "en", "native_lang": native, "level": "b1"})
+		c.expect("group_joined")
+		for _, other := range clients[:i] {
+			other.expect("group_member_joined")
+		}
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+func TestDisplayModesShapeEachRecipientsMessage(t *testing.T) {
+	group := newTranslatedGroup(t, &services.FakeTranslator{}, "pt", "es", "fr")
+	pt, es, fr := group[0], group[1], group[2]
+
+	es.send("set_display_mode", map[string]string{"mode": "translated"})
+	if got := es.expect("display_mode"); got["mode"] != "translated" {
+		t.Errorf("display_mode = %v", got)
+	}
+	fr.send("set_display_mode", map[string]string{"mode": "original"})
+	fr.expect("display_mode")
+	fr.send("set_display_mode", map[string]string{"mode": "romanized"})
+	if got := fr.expect("display_mode_error"); got["error"] != "invalid_mode" {
+		t.Errorf("display_mode_error = %v", got)
+	}
+
+	pt.send("chat_message", map[string]string{"text": "olá"})
+
+	// translated: só a tradução, no idioma de cada um
+	pending := es.expect("chat_message")
+	if _, ok := pending["text"]; ok || pending["translation_status"] != "pending" {
+		t.Errorf("pending for translated mode = %v", pending)
+	}
+	final := es.expectFinal()
+	if _, ok := final["text"]; ok || final["translated_text"] != "[es] olá" || final["target_language"] != "es" || final["display_mode"] != "translated" {
+		t.Errorf("final for translated mode = %v", final)
+	}
+
+	// original: uma mensagem só, sem tradução
+	got := fr.expect("chat_message")
+	if got["text"] != "olá" || got["translated_text"] != nil || got["translation_status"] != nil || got["display_mode"] != "original" {
+		t.Errorf("original mode = %v", got)
+	}
+	fr.expectNone("chat_message", 100*time.Millisecond)
+
+	// both (padrão): original e tradução
+	fr.send("chat_message", map[string]string{"text": "salut"})
+	final = pt.expectFinal()
+	if final["text"] != "salut" || final["translated_text"] != "[pt] salut" || final["target_language"] != "pt" || final["display_mode"] != "both" {
+		t.Errorf("both mode = %v", final)
+	}
+	if final := es.expectFinal(); final["translated_text"] != "[es] salut" {
+		t.Errorf("es got %v", final)
+	}
+}
+
+func TestGroupTranslatesOncePerRecipientLanguage(t *testing.T) {
+	translator := &recordingTranslator{requests: make(chan services.TranslationRequest, 8)}
+	group := newTranslatedGroup(t, translator, "pt", "es", "en", "es")
+
+	group[0].send("chat_message", map[string]string{"text": "bom dia"})
+	want := map[int]string{1: "[es] bom dia", 2: "[en] bom dia", 3: "[es] bom dia"}
+	for i, text := range want {
+		if final := group[i].expectFinal(); final["translated_text"] != text || final["source_language"] != "pt" {
+			t.Errorf("member %d final = %v", i, final)
+		}
+	}
+	group[0].expectNone("chat_message", 100*time.Millisecond)
+
+	// Um pedido por idioma, não por destinatário
+	targets := map[string]int{}
+	for len(translator.requests) > 0 {
+		targets[(<-translator.requests).Target]++
+	}
+	if len(targets) != 2 || targets["es"] != 1 || targets["en"] != 1 {
+		t.Errorf("translation requests by target = %v", targets)
+	}
+}
+
+func TestDisplayModeNeedsARoom(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	alice := dialWS(t, serveWS(t, h), "alice", "")
+	alice.send("set_display_mode", map[string]string{"mode": "original"})
+	if got := alice.expect("display_mode_error"); got["error"] != "not_in_room" {
+		t.Errorf("display_mode_error = %v", got)
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// blockingTranslator só responde depois que o teste libera release
+type blockingTranslator struct {
+	services.FakeTranslator
+	started chan string
+	release chan struct{}
+}
+
+func newBlockingTranslator() *blockingTranslator {
+	return &blockingTranslator{started: make(chan string, 8), release: make(chan struct{})}
+}
+
+func (b *blockingTranslator) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	b.started <- req.Text
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return b.FakeTranslator.Translate(ctx, req)
+}
+
+func TestSlowTranslationDoesNotBlockSignaling(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	translator := newBlockingTranslator()
+	h.Translator = translator
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, alice, bob)
+
+	alice.send("chat_message", map[string]string{"text": "oi"})
+	<-translator.started
+
+	// O original chega antes da tradução, mesmo sem streaming
+	pending := bob.expect("chat_message")
+	if pending["text"] != "oi" || pending["translation_status"] != "pending" || pending["streaming"] != nil {
+		t.Errorf("pending chat_message = %v", pending)
+	}
+
+	// O loop de leitura de alice segue livre enquanto a tradução espera
+	alice.send("webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "offer", "sdp": testSDP}})
+	bob.expect("webrtc_offer")
+
+	close(translator.release)
+	final := bob.expect("chat_message")
+	if final["translation_status"] != "ok" || final["message_id"] != pending["message_id"] {
+		t.Errorf("final chat_message = %v", final)
+	}
+}
+
+// newDetectingPair pareia alice e bob (cada um com seu par de idiomas) num
+// handler com detecção local e um provedor que grava os pedidos
+func newDetectingPair(t *testing.T, alice, bob services.MatchRequest) (a, b *wsClient, translator *recordingTranslator) {
+	t.Helper()
+	h, _ := newTestWSHandler(t)
+	translator = &recordingTranslator{requests: make(chan services.TranslationRequest, 8)}
+	h.Translator = translator
+	h.LanguageDetector = services.NewNgramDetector()
+	srv := serveWS(t, h)
+	a, b = dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	a.send("join_queue", alice)
+	a.expect("queue_joined")
+	b.send("join_queue", bob)
+	a.expect("matched")
+	b.expect("matched")
+	return a, b, translator
+}
+
+// expectFinal pula o chat_message pendente e devolve o definitivo
+func (c *wsClient) expectFinal() map[string]interface{} {
+	c.t.Helper()
+	for {
+		if msg := c.expect("chat_message"); msg["translation_status"] != "pending" {
+			return msg
+		}
+	}
+}
+
+func TestSameLanguageRecipientIsSkipped(t *testing.T) {
+	alice, bob, translator := newDetectingPair(t,
+		services.MatchRequest{NativeLanguage: "pt", TargetLanguage: "en"},
+		services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+
+	// alice pratica inglês: para bob não há o que traduzir
+	text := "I think the weather is nice today"
+	alice.send("chat_message", map[string]string{"text": text})
+	got := bob.expect("chat_message")
+	if got["translation_status"] != "skipped" || got["translated_text"] != text || got["source_language"] != "en" || got["detected_language"] != "en" {
+		t.Errorf("chat_message = %v", got)
+	}
+	select {
+	case req := <-translator.requests:
+		t.Errorf("provider called for a skipped message: %+v", req)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLanguageChangeIsFlagged(t *testing.T) {
+	alice, bob, _ := newDetectingPair(t,
+		services.MatchRequest{NativeLanguage: "pt", TargetLanguage: "en"},
+		services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+
+	alice.send("chat_message", map[string]string{"text": "onde você trabalha atualmente?"})
+	if got := bob.expectFinal(); got["language_changed"] != false || got["source_language"] != "pt" {
+		t.Errorf("first message = %v", got)
+	}
+	alice.send("chat_message", map[string]string{"text": "I think the weather is nice today"})
+	if got := bob.expectFinal(); got["language_changed"] != true || got["source_language"] != "en" {
+		t.Errorf("switch to english = %v", got)
+	}
+}
+
+func TestSharedScriptIsNotTakenForRecipientLanguage(t *testing.T) {
+	alice, bob, translator := newDetectingPair(t,
+		services.MatchRequest{NativeLanguage: "uk", TargetLanguage: "ru"},
+		services.MatchRequest{NativeLanguage: "ru", TargetLanguage: "uk"})
+
+	// Ucraniano em cirílico não pode passar por russo e ser pulado
+	alice.send("chat_message", map[string]string{"text": "Привіт, як справи? Що ти робиш сьогодні ввечері?"})
+	if got := bob.expectFinal(); got["translation_status"] != "ok" || got["source_language"] != "uk" {
+		t.Errorf("chat_message = %v", got)
+	}
+	if req := <-translator.requests; req.Source != "uk" || req.Detected != "" {
+		t.Errorf("request = %+v, want the declared source", req)
+	}
+}
+
+// expectStatus devolve o primeiro chat_message com o translation_status dado
+func (c *wsClient) expectStatus(status string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		if msg := c.expect("chat_message"); msg["translation_status"] == status {
+			return msg
+		}
+	}
+}
+
+func TestFullTranslationQueueDegrades(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	translator := newBlockingTranslator()
+	translator.started = make(chan string, 32)
+	t.Cleanup(func() { close(translator.release) })
+	h.Translator = translator
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, alice, bob)
+
+	// 4 traduções em andamento e 16 na fila ocupam a sala inteira
+	for i := 0; i < 20; i++ {
+		alice.send("chat_message", map[string]string{"text": fmt.Sprintf("mensagem %d", i)})
+	}
+	alice.send("chat_message", map[string]string{"text": "sem vaga"})
+	got := bob.expectStatus("degraded")
+	if got["text"] != "sem vaga" || got["translated_text"] != "sem vaga" {
+		t.Errorf("degraded chat_message = %v", got)
+	}
+}
+
+func TestOpenBreakerDegradesChatAndShowsInHealth(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	breaker := &services.CircuitBreaker{Threshold: 1, Cooldown: time.Minute}
+	h.Translator = &services.ResilientTranslator{Next: &services.FakeTranslator{}, Breaker: breaker}
+	breaker.Failure()
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, alice, bob)
+
+	alice.send("chat_message", map[string]string{"text": "oi"})
+	if got := bob.expectFinal(); got["translation_status"] != "degraded" || got["translated_text"] != "oi" {
+		t.Errorf("chat_message = %v", got)
+	}
+
+	r := gin.New()
+	r.GET("/health", (&controllers.HealthHandler{Translator: h.Translator}).HandleHealth)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health struct {
+		Provider string                           `json:"translation_provider"`
+		Breakers map[string]services.BreakerState `json:"translation_breakers"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &health)
+	if w.Code != http.StatusOK || health.Provider != "fake" || health.Breakers["fake"].State != services.BreakerOpen || health.Breakers["fake"].OpenedAt == nil {
+		t.Errorf("health = %d %s", w.Code, w.Body)
+	}
+}
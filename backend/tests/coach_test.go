@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestFakeCoachExplainsEachEdit(t *testing.T) {
+	coach := &services.FakeCoach{Fixes: map[string]string{"goed": "went", "yesterday": "yesterday,"}}
+	got, err := coach.Correct(context.Background(), services.CoachRequest{Text: "I goed to the beach", Language: "en", NativeLanguage: "pt"})
+	if err != nil {
+		t.Fatalf("Correct: %v", err)
+	}
+	if got.Corrected != "I went to the beach" || len(got.Edits) != 1 {
+		t.Fatalf("correction = %+v", got)
+	}
+	if e := got.Edits[0]; e.Original != "goed" || e.Replacement != "went" || e.Explanation == "" {
+		t.Errorf("edit = %+v", e)
+	}
+	if got.Level != "A2" {
+		t.Errorf("level = %q", got.Level)
+	}
+}
+
+// newCoachedPair pareia alice (pt, praticando en) com bob e liga o coach de alice
+func newCoachedPair(t *testing.T, coach services.Coach) (alice, bob *wsClient, roomID string) {
+	t.Helper()
+	h, _ := newTestWSHandler(t)
+	h.Coach = coach
+	h.LanguageDetector = services.NewNgramDetector()
+	srv := serveWS(t, h)
+	alice, bob = dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	roomID = pairClients(t, alice, bob)
+
+	alice.send("set_coach_mode", map[string]bool{"enabled": true})
+	if got := alice.expect("coach_mode"); got["enabled"] != true {
+		t.Fatalf("coach_mode = %v", got)
+	}
+	return alice, bob, roomID
+}
+
+func TestCorrectionGoesOnlyToSender(t *testing.T) {
+	alice, bob, _ := newCoachedPair(t, &services.FakeCoach{Fixes: map[string]string{"goed": "went"}})
+
+	alice.send("chat_message", map[string]string{"text": "yesterday I goed to the beach with my friends"})
+	got := alice.expect("correction")
+	if got["corrected"] != "yesterday I went to the beach with my friends" || got["language"] != "en" {
+		t.Errorf("correction = %v", got)
+	}
+	bob.expect("chat_message")
+	bob.expectNone("correction", 100*time.Millisecond)
+}
+
+func TestCoachOnlyReviewsTargetLanguage(t *testing.T) {
+	alice, bob, _ := newCoachedPair(t, &services.FakeCoach{})
+
+	// Em português (idioma nativo de alice) não há o que revisar
+	alice.send("chat_message", map[string]string{"text": "ontem eu fui para a praia com os meus amigos"})
+	bob.expect("chat_message")
+	alice.expectNone("correction", 100*time.Millisecond)
+
+	// O modo é de alice: bob praticando português não recebe correção
+	bob.send("chat_message", map[string]string{"text": "ontem eu fui para a praia com os meus amigos"})
+	alice.expect("chat_message")
+	bob.expectNone("correction", 100*time.Millisecond)
+}
+
+func TestCoachModeIsPerRoom(t *testing.T) {
+	alice, bob, first := newCoachedPair(t, &services.FakeCoach{})
+
+	// next reabre a dupla numa sala nova, com o coach desligado
+	alice.send("next", nil)
+	if got := alice.expect("matched")["room_id"]; got == first {
+		t.Fatalf("next kept room %v", got)
+	}
+	bob.expect("matched")
+
+	alice.send("chat_message", map[string]string{"text": "yesterday I went to the beach with my friends"})
+	bob.expect("chat_message")
+	alice.expectNone("correction", 100*time.Millisecond)
+}
+
+// failingCoach sempre falha com erro transitório e conta as chamadas
+type failingCoach struct {
+	services.FakeTranslator
+	calls atomic.Int32
+}
+
+func (f *failingCoach) Correct(ctx context.Context, req services.CoachRequest) (*services.Correction, error) {
+	f.calls.Add(1)
+	return nil, &services.ProviderError{Provider: "fake", StatusCode: http.StatusServiceUnavailable}
+}
+
+func TestCoachFailureGoesThroughBreaker(t *testing.T) {
+	next := &failingCoach{}
+	coach := &services.ResilientTranslator{Next: next, Retries: 1, Backoff: time.Millisecond, Breaker: &services.CircuitBreaker{Threshold: 1, Cooldown: time.Minute}}
+	alice, _, _ := newCoachedPair(t, coach)
+	text := "yesterday I went to the beach with my friends"
+
+	alice.send("chat_message", map[string]string{"text": text})
+	if got := alice.expect("correction_failed"); got["error"] != "correction_failed" || got["message_id"] == "" {
+		t.Errorf("correction_failed = %v", got)
+	}
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2 (one retry)", n)
+	}
+
+	// Com o breaker aberto o provedor nem é chamado
+	alice.send("chat_message", map[string]string{"text": text})
+	if got := alice.expect("correction_failed"); got["error"] != "coach_unavailable" {
+		t.Errorf("correction_failed = %v", got)
+	}
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("calls = %d after breaker opened", n)
+	}
+}
+
+func TestCoachSharesTranslationBreaker(t *testing.T) {
+	t.Setenv("COACH_PROVIDER", "gemini")
+	resilient := &services.ResilientTranslator{Next: &failingCoach{}, Retries: 0, Breaker: &services.CircuitBreaker{Threshold: 1, Cooldown: time.Minute}}
+	chain := &services.MeteredTranslator{Next: &services.GlossaryTranslator{Next: &services.ChainTranslator{Translators: []services.Translator{resilient}}}}
+
+	coach := services.NewCoach(chain)
+	if coach != services.Coach(resilient) {
+		t.Fatalf("coach = %T, want the chain's resilient provider", coach)
+	}
+	// Uma falha do coach abre o breaker que o /health mostra para a tradução
+	coach.Correct(context.Background(), services.CoachRequest{Text: "hi", Language: "en"})
+	if state := services.TranslatorBreakers(chain)["fake"]; state.State != services.BreakerOpen {
+		t.Errorf("translation breaker = %+v", state)
+	}
+}
+
+// blockingCoach só responde quando o teste libera release
+type blockingCoach struct {
+	services.FakeCoach
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingCoach) Correct(ctx context.Context, req services.CoachRequest) (*services.Correction, error) {
+	b.started <- req.Text
+	<-b.release
+	return b.FakeCoach.Correct(ctx, req)
+}
+
+func TestBusyCoachRejectsInsteadOfQueueing(t *testing.T) {
+	coach := &blockingCoach{started: make(chan string, 8), release: make(chan struct{})}
+	alice, _, _ := newCoachedPair(t, coach)
+
+	texts := []string{
+		"yesterday I went to the beach with my friends",
+		"I think the weather is nice today",
+		"where are you from, do you like music",
+	}
+	for _, text := range texts[:2] {
+		alice.send("chat_message", map[string]string{"text": text})
+		<-coach.started
+	}
+	alice.send("chat_message", map[string]string{"text": texts[2]})
+	if got := alice.expect("correction_failed"); got["error"] != "coach_busy" {
+		t.Errorf("correction_failed = %v", got)
+	}
+
+	close(coach.release)
+	alice.expect("correction")
+	alice.expect("correction")
+}
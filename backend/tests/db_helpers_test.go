@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB abre um SQLite em memória com o schema dos models. sessions é criada
+// à mão porque o default:now() do Postgres não existe no SQLite.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.User{}, &models.Report{}, &models.Block{}, &models.ReputationEvent{}, &models.Rating{}, &models.GlossaryEntry{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	err = db.Exec(`CREATE TABLE sessions (
+		id text PRIMARY KEY,
+		user_id text NOT NULL,
+		partner_id text,
+		start_time datetime,
+		end_time datetime,
+		room_id text NOT NULL UNIQUE,
+		translation_count integer DEFAULT 0,
+		avg_latency real DEFAULT 0
+	)`).Error
+	if err != nil {
+		t.Fatalf("create sessions: %v", err)
+	}
+	return db
+}
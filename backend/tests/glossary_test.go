@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestGlossaryEntriesBelongToTheirOwner(t *testing.T) {
+	gs := &services.GlossaryService{DB: newTestDB(t)}
+	entry, err := gs.Create("alice", services.GlossaryInput{Term: "Nexus"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if entries, _ := gs.List("bob"); len(entries) != 0 {
+		t.Errorf("bob lists %+v", entries)
+	}
+	if _, err := gs.Update("bob", entry.ID, services.GlossaryInput{Term: "Nexo"}); err != services.ErrGlossaryEntryNotFound {
+		t.Errorf("bob updated alice's entry: %v", err)
+	}
+	if removed, err := gs.Delete("bob", entry.ID); removed || err != nil {
+		t.Errorf("bob deleted alice's entry: %v, %v", removed, err)
+	}
+
+	// O mesmo termo pode existir no glossário de outro usuário, mas não duas vezes no mesmo
+	if _, err := gs.Create("bob", services.GlossaryInput{Term: "nexus"}); err != nil {
+		t.Errorf("bob Create: %v", err)
+	}
+	if _, err := gs.Create("alice", services.GlossaryInput{Term: "NEXUS"}); err != services.ErrGlossaryEntryExists {
+		t.Errorf("duplicate = %v", err)
+	}
+	if removed, err := gs.Delete("alice", entry.ID); !removed || err != nil {
+		t.Errorf("alice Delete = %v, %v", removed, err)
+	}
+}
+
+func TestRoomGlossaryPrefersTheFirstUser(t *testing.T) {
+	gs := &services.GlossaryService{DB: newTestDB(t)}
+	gs.Create("alice", services.GlossaryInput{Term: "vox", Translation: "Vox", SourceLanguage: "pt", TargetLanguage: "en"})
+	gs.Create("bob", services.GlossaryInput{Term: "Vox", Translation: "VoxBridge", SourceLanguage: "pt", TargetLanguage: "en"})
+	gs.Create("bob", services.GlossaryInput{Term: "Rio"})
+
+	terms, err := gs.RoomGlossary("bob", "alice")
+	if err != nil || len(terms) != 2 {
+		t.Fatalf("RoomGlossary = %+v, %v", terms, err)
+	}
+	for _, term := range terms {
+		if strings.EqualFold(term.Term, "vox") && term.Translation != "VoxBridge" {
+			t.Errorf("vox = %+v, want bob's entry", term)
+		}
+	}
+}
+
+// newGlossaryRouter monta as rotas /v1/glossary com o usuário vindo do cabeçalho X-User
+func newGlossaryRouter(h *controllers.GlossaryHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := func(c *gin.Context) { c.Set("user_id", c.GetHeader("X-User")) }
+	r.GET("/v1/glossary", auth, h.HandleListGlossary)
+	r.POST("/v1/glossary", auth, h.HandleCreateGlossaryEntry)
+	r.PUT("/v1/glossary/:id", auth, h.HandleUpdateGlossaryEntry)
+	r.DELETE("/v1/glossary/:id", auth, h.HandleDeleteGlossaryEntry)
+	return r
+}
+
+func glossaryRequest(r *gin.Engine, userID, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-User", userID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGlossaryCRUDHandlers(t *testing.T) {
+	r := newGlossaryRouter(&controllers.GlossaryHandler{GlossaryService: &services.GlossaryService{DB: newTestDB(t)}})
+
+	w := glossaryRequest(r, "alice", http.MethodPost, "/v1/glossary", `{"term": " saudade "}`)
+	var entry models.GlossaryEntry
+	if w.Code != http.StatusCreated || json.Unmarshal(w.Body.Bytes(), &entry) != nil || entry.Term != "saudade" {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+
+	for _, tc := range []struct {
+		name, user, method, path, body string
+		code                           int
+		errCode                        string
+	}{
+		{"invalid json", "alice", http.MethodPost, "/v1/glossary", `{`, http.StatusBadRequest, "invalid_request"},
+		{"empty term", "alice", http.MethodPost, "/v1/glossary", `{"term": " "}`, http.StatusBadRequest, "term_required"},
+		{"mapping without pair", "alice", http.MethodPost, "/v1/glossary", `{"term": "vox", "translation": "VoxBridge"}`, http.StatusBadRequest, "language_pair_required"},
+		{"duplicate", "alice", http.MethodPost, "/v1/glossary", `{"term": "Saudade"}`, http.StatusConflict, "glossary_entry_exists"},
+		{"update by other user", "bob", http.MethodPut, "/v1/glossary/" + entry.ID, `{"term": "longing"}`, http.StatusNotFound, "glossary_entry_not_found"},
+		{"delete by other user", "bob", http.MethodDelete, "/v1/glossary/" + entry.ID, ``, http.StatusNotFound, "glossary_entry_not_found"},
+		{"update", "alice", http.MethodPut, "/v1/glossary/" + entry.ID, `{"term": "saudade", "translation": "longing", "source_lang": "PT", "target_lang": "en"}`, http.StatusOK, ""},
+	} {
+		w := glossaryRequest(r, tc.user, tc.method, tc.path, tc.body)
+		if w.Code != tc.code || (tc.errCode != "" && !strings.Contains(w.Body.String(), `"`+tc.errCode+`"`)) {
+			t.Errorf("%s: %d %s", tc.name, w.Code, w.Body)
+		}
+	}
+
+	w = glossaryRequest(r, "alice", http.MethodGet, "/v1/glossary", ``)
+	var list struct {
+		Entries []models.GlossaryEntry `json:"entries"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Entries) != 1 || list.Entries[0].Translation != "longing" || list.Entries[0].SourceLanguage != "pt" {
+		t.Errorf("list = %s", w.Body)
+	}
+	if w := glossaryRequest(r, "bob", http.MethodGet, "/v1/glossary", ``); strings.Contains(w.Body.String(), "saudade") {
+		t.Errorf("bob sees alice's glossary: %s", w.Body)
+	}
+
+	if w := glossaryRequest(r, "alice", http.MethodDelete, "/v1/glossary/"+entry.ID, ``); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d %s", w.Code, w.Body)
+	}
+	if w := glossaryRequest(r, "alice", http.MethodDelete, "/v1/glossary/"+entry.ID, ``); w.Code != http.StatusNotFound {
+		t.Errorf("second delete: %d", w.Code)
+	}
+}
+
+func TestRoomKeepsGlossaryUntilItIsEdited(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	h.Translator = &services.GlossaryTranslator{Next: &services.FakeTranslator{Words: map[string]string{"Nexus": "Nexo", "rocks": "pedras"}}}
+	gs := &services.GlossaryService{DB: newTestDB(t)}
+	h.GlossaryService = gs
+	r := newGlossaryRouter(&controllers.GlossaryHandler{GlossaryService: gs, WS: h})
+
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, alice, bob)
+
+	entry, _ := gs.Create("alice", services.GlossaryInput{Term: "Nexus"})
+	alice.send("chat_message", map[string]string{"text": "Nexus rocks"})
+	if got := bob.expectFinal(); got["translated_text"] != "[en] Nexus pedras" {
+		t.Fatalf("first message = %v", got)
+	}
+
+	// Apagado direto no banco: a sala continua com o glossário que carregou
+	gs.Delete("alice", entry.ID)
+	alice.send("chat_message", map[string]string{"text": "Nexus rocks"})
+	if got := bob.expectFinal(); got["translated_text"] != "[en] Nexus pedras" {
+		t.Errorf("cached message = %v", got)
+	}
+
+	// Editar pela API descarta o cache
+	if w := glossaryRequest(r, "alice", http.MethodPost, "/v1/glossary", `{"term": "rocks"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	alice.send("chat_message", map[string]string{"text": "Nexus rocks"})
+	if got := bob.expectFinal(); got["translated_text"] != "[en] Nexo rocks" {
+		t.Errorf("after edit = %v", got)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func newTestGroupService(t *testing.T, capacity int) (*services.GroupService, *miniredis.Miniredis) {
+	t.Helper()
+	rdb, mr := newTestRedis(t)
+	return &services.GroupService{Redis: rdb, Capacity: capacity}, mr
+}
+
+func TestGroupJoinFillsRoomsUpToCapacity(t *testing.T) {
+	gs, _ := newTestGroupService(t, 3)
+	ctx := context.Background()
+
+	rooms := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		room, err := gs.Join(ctx, services.GroupRequest{UserID: fmt.Sprintf("u%d", i), NativeLanguage: "pt", TargetLanguage: "en", Level: "b1"})
+		if err != nil {
+			t.Fatalf("Join: %v", err)
+		}
+		rooms[room.ID] = len(room.Members)
+	}
+	if len(rooms) != 2 {
+		t.Fatalf("rooms = %v, want a full room and a new one", rooms)
+	}
+
+	// Outro nível não entra na mesma mesa
+	other, _ := gs.Join(ctx, services.GroupRequest{UserID: "c1", NativeLanguage: "pt", TargetLanguage: "en", Level: "C1"})
+	if _, ok := rooms[other.ID]; ok || len(other.Members) != 1 {
+		t.Errorf("C1 learner joined a B1 room: %+v", other)
+	}
+
+	if _, err := gs.Join(ctx, services.GroupRequest{UserID: "x", TargetLanguage: "en", Level: "expert"}); err != services.ErrInvalidGroupLevel {
+		t.Errorf("invalid level accepted: %v", err)
+	}
+}
+
+func TestGroupStaysAliveWithTwoMembers(t *testing.T) {
+	gs, mr := newTestGroupService(t, 6)
+	ctx := context.Background()
+
+	var roomID string
+	for _, id := range []string{"a", "b", "c"} {
+		room, _ := gs.Join(ctx, services.GroupRequest{UserID: id, NativeLanguage: "pt", TargetLanguage: "ja"})
+		roomID = room.ID
+	}
+
+	room, err := gs.Leave(ctx, "a")
+	if err != nil || room.Closed || len(room.Members) != 2 {
+		t.Fatalf("after first leave: %+v, %v", room, err)
+	}
+
+	room, err = gs.Leave(ctx, "b")
+	if err != nil || !room.Closed || len(room.Evicted) != 1 || room.Evicted[0] != "c" {
+		t.Fatalf("after second leave: %+v, %v", room, err)
+	}
+	if mr.Exists("group:members:"+roomID) || mr.Exists("group:user:c") {
+		t.Error("closed group left state behind")
+	}
+
+	// Um novo usuário abre outra mesa em vez de cair na que foi encerrada
+	next, _ := gs.Join(ctx, services.GroupRequest{UserID: "d", NativeLanguage: "pt", TargetLanguage: "ja"})
+	if next.ID == roomID {
+		t.Error("joined a closed group")
+	}
+}
+
+func TestRoomMembershipChangesOnlyThatMember(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+
+	for _, id := range []string{"a", "b", "c", "a"} {
+		if err := ms.AddRoomMember("group_1", id); err != nil {
+			t.Fatalf("AddRoomMember: %v", err)
+		}
+	}
+	ms.RemoveRoomMember("group_1", "b")
+
+	members, _ := ms.RoomMembers("group_1")
+	if fmt.Sprint(members) != "[c a]" {
+		t.Errorf("members = %v, want [c a]", members)
+	}
+	if room, _ := ms.CurrentRoom("b"); room != "" {
+		t.Errorf("b still in %q", room)
+	}
+	if room, _ := ms.CurrentRoom("c"); room != "group_1" {
+		t.Errorf("c room = %q", room)
+	}
+}
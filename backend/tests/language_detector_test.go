@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestNgramDetectorLabelsChatMessages(t *testing.T) {
+	d := services.NewNgramDetector()
+	cases := map[string]string{
+		"I think the weather is nice today":   "en",
+		"onde você trabalha atualmente?":      "pt",
+		"me encanta el fútbol y la playa":     "es",
+		"ich weiß nicht genau, was du meinst": "de",
+		"こんにちは、元気ですか":                         "ja",
+	}
+	for text, want := range cases {
+		got, err := d.Detect(context.Background(), text)
+		if err != nil {
+			t.Fatalf("Detect(%q): %v", text, err)
+		}
+		if got.Language != want || got.Confidence < services.MinDetectionConfidence {
+			t.Errorf("Detect(%q) = %+v, want %s", text, got, want)
+		}
+	}
+}
+
+func TestNgramDetectorIsUnsureAboutAmbiguousText(t *testing.T) {
+	d := services.NewNgramDetector()
+	for _, text := range []string{
+		// Escritas de vários idiomas: ucraniano, persa e russo ficam todos no palpite
+		"Привіт, як справи? Що ти робиш сьогодні ввечері?",
+		"سلام، حالت چطوره؟ امروز چه کار می‌کنی؟",
+		"привет, как дела?",
+		// Latinos sem perfil, que ficam perto de um perfil qualquer
+		"hej hur mår du idag, jag mår bra tack",
+		"jag vet inte riktigt vad du menar med det",
+		"nie wiem dokładnie co masz na myśli",
+	} {
+		got, _ := d.Detect(context.Background(), text)
+		if got.Confidence >= services.MinDetectionConfidence {
+			t.Errorf("Detect(%q) = %+v, should not be trusted", text, got)
+		}
+	}
+}
+
+func TestNgramDetectorIsUnsureAboutShortMessages(t *testing.T) {
+	d := services.NewNgramDetector()
+	for _, text := range []string{"ok", "haha", "👍", "?"} {
+		got, _ := d.Detect(context.Background(), text)
+		if got.Confidence >= services.MinDetectionConfidence {
+			t.Errorf("Detect(%q) = %+v, should not be trusted", text, got)
+		}
+	}
+}
+
+func TestHTTPTranslatorDetect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/detect" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"language":"pt","confidence":87.0}]`))
+	}))
+	defer srv.Close()
+
+	got, err := services.NewHTTPTranslator(srv.URL, "").Detect(context.Background(), "bom dia")
+	if err != nil || got.Language != "pt" || got.Confidence != 0.87 {
+		t.Errorf("Detect = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+const (
+	testLiveKitKey    = "APItestkey"
+	testLiveKitSecret = "test-secret-with-enough-entropy-123"
+)
+
+func newTestLiveKit(url string) *services.LiveKitService {
+	return &services.LiveKitService{
+		URL:       url,
+		APIKey:    testLiveKitKey,
+		APISecret: testLiveKitSecret,
+		TokenTTL:  time.Hour,
+	}
+}
+
+func parseLiveKitToken(t *testing.T, token string) *services.LiveKitClaims {
+	t.Helper()
+	claims := &services.LiveKitClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(testLiveKitSecret), nil
+	})
+	if err != nil {
+		t.Fatalf("token did not verify: %v", err)
+	}
+	return claims
+}
+
+func TestParticipantTokenIsScopedToRoom(t *testing.T) {
+	lk := newTestLiveKit("wss://sfu.example.com")
+
+	token, err := lk.ParticipantToken("user-1", "NexusPeer_user", "room_user-1_user-2")
+	if err != nil {
+		t.Fatalf("ParticipantToken: %v", err)
+	}
+	claims := parseLiveKitToken(t, token)
+
+	if claims.Issuer != testLiveKitKey {
+		t.Errorf("iss = %q, want %q", claims.Issuer, testLiveKitKey)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("sub = %q, want user-1", claims.Subject)
+	}
+	if claims.ExpiresAt == nil || time.Until(claims.ExpiresAt.Time) > time.Hour {
+		t.Errorf("exp not bounded by TokenTTL: %v", claims.ExpiresAt)
+	}
+
+	v := claims.Video
+	if v == nil {
+		t.Fatal("missing video grant")
+	}
+	if !v.RoomJoin || v.Room != "room_user-1_user-2" {
+		t.Errorf("grant = %+v, want join on room_user-1_user-2 only", v)
+	}
+	if v.RoomCreate || v.RoomList || v.RoomAdmin || v.RoomRecord {
+		t.Errorf("participant token must not carry admin grants: %+v", v)
+	}
+}
+
+func TestParticipantTokenRejectsOtherSecret(t *testing.T) {
+	lk := newTestLiveKit("wss://sfu.example.com")
+	token, _ := lk.ParticipantToken("user-1", "", "room_a")
+
+	_, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return []byte("another-secret"), nil
+	})
+	if err == nil {
+		t.Fatal("token verified with the wrong secret")
+	}
+}
+
+func TestCreateRoomCallsRoomService(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	var gotClaims *services.LiveKitClaims
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotClaims = &services.LiveKitClaims{}
+		jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), gotClaims, func(t *jwt.Token) (interface{}, error) {
+			return []byte(testLiveKitSecret), nil
+		})
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	lk := newTestLiveKit(strings.Replace(srv.URL, "http://", "ws://", 1))
+	if err := lk.CreateRoom(context.Background(), "room_a", 2); err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+
+	if gotPath != "/twirp/livekit.RoomService/CreateRoom" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotBody["name"] != "room_a" || gotBody["max_participants"] != float64(2) {
+		t.Errorf("body = %v", gotBody)
+	}
+	if gotClaims.Video == nil || !gotClaims.Video.RoomCreate || gotClaims.Video.RoomJoin {
+		t.Errorf("server token grant = %+v, want roomCreate only", gotClaims.Video)
+	}
+}
+
+// decodeJWTPart lê o header (0) ou o payload (1) do JWT sem validar, para ver os nomes crus dos campos
+func decodeJWTPart(t *testing.T, token string, part int) map[string]interface{} {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a JWT: %q", token)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[part])
+	if err != nil {
+		t.Fatalf("decode part %d: %v", part, err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("unmarshal part %d: %v", part, err)
+	}
+	return out
+}
+
+// Sem o server-sdk-go, o formato do auth.AccessToken fica fixado aqui: o SFU
+// lê exatamente esses campos
+func TestParticipantTokenMatchesAccessTokenFormat(t *testing.T) {
+	lk := newTestLiveKit("wss://sfu.example.com")
+	before := time.Now().Unix()
+	token, _ := lk.ParticipantToken("user-1", "NexusPeer_user", "room_a")
+
+	if header := decodeJWTPart(t, token, 0); header["alg"] != "HS256" || header["typ"] != "JWT" {
+		t.Errorf("header = %v", header)
+	}
+
+	claims := decodeJWTPart(t, token, 1)
+	want := map[string]interface{}{
+		"iss":  testLiveKitKey,
+		"sub":  "user-1",
+		"jti":  "user-1",
+		"name": "NexusPeer_user",
+		"video": map[string]interface{}{
+			"roomJoin":       true,
+			"room":           "room_a",
+			"canPublish":     true,
+			"canSubscribe":   true,
+			"canPublishData": true,
+		},
+	}
+	for key, value := range want {
+		got, _ := json.Marshal(claims[key])
+		expected, _ := json.Marshal(value)
+		if string(got) != string(expected) {
+			t.Errorf("claim %s = %s, want %s", key, got, expected)
+		}
+	}
+	nbf, _ := claims["nbf"].(float64)
+	exp, _ := claims["exp"].(float64)
+	if int64(nbf) < before-1 || int64(nbf) > time.Now().Unix() || int64(exp)-int64(nbf) != int64(time.Hour/time.Second) {
+		t.Errorf("nbf = %v, exp = %v", claims["nbf"], claims["exp"])
+	}
+	for key := range claims {
+		if _, ok := want[key]; !ok && key != "nbf" && key != "exp" {
+			t.Errorf("unexpected claim %q", key)
+		}
+	}
+}
+
+func TestCreateRoomTwirpRequestShape(t *testing.T) {
+	type call struct {
+		method, path, contentType string
+		body                      map[string]interface{}
+		claims                    map[string]interface{}
+	}
+	calls := make(chan call, 2)
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c call
+		c.method, c.path, c.contentType = r.Method, r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&c.body)
+		if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != r.Header.Get("Authorization") {
+			c.claims = decodeJWTPart(t, token, 1)
+		}
+		calls <- c
+		if code := int(status.Load()); code != http.StatusOK {
+			// Erro no formato do Twirp
+			w.WriteHeader(code)
+			w.Write([]byte(`{"code":"permission_denied","msg":"invalid token"}`))
+			return
+		}
+		w.Write([]byte(`{"sid":"RM_x","name":"room_g"}`))
+	}))
+	defer srv.Close()
+
+	// Clientes usam ws(s)://; a API é o mesmo host em http(s)://
+	lk := newTestLiveKit(strings.Replace(srv.URL, "http://", "ws://", 1) + "/")
+	if err := lk.CreateRoom(context.Background(), "room_g", 4); err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+
+	c := <-calls
+	if c.method != http.MethodPost || c.path != "/twirp/livekit.RoomService/CreateRoom" || c.contentType != "application/json" {
+		t.Errorf("request = %s %s (%s)", c.method, c.path, c.contentType)
+	}
+	body, _ := json.Marshal(c.body)
+	if string(body) != `{"empty_timeout":300,"max_participants":4,"name":"room_g"}` {
+		t.Errorf("body = %s", body)
+	}
+	if c.claims == nil || c.claims["iss"] != testLiveKitKey {
+		t.Fatalf("claims = %v", c.claims)
+	}
+	if grant, _ := json.Marshal(c.claims["video"]); string(grant) != `{"roomCreate":true}` {
+		t.Errorf("server grant = %s", grant)
+	}
+
+	status.Store(http.StatusUnauthorized)
+	if err := lk.CreateRoom(context.Background(), "room_g", 4); err == nil {
+		t.Error("Twirp error not reported")
+	}
+	<-calls
+}
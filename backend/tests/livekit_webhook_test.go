@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+const participantJoinedFixture = `{
+  "event": "participant_joined",
+  "id": "EV_3kLp9xQ2",
+  "createdAt": "1717000000",
+  "room": {"sid": "RM_a1b2c3", "name": "room_user-1_user-2"},
+  "participant": {"sid": "PA_d4e5f6", "identity": "user-2", "name": "NexusPeer_user"}
+}`
+
+const roomFinishedFixture = `{
+  "event": "room_finished",
+  "id": "EV_9mZt4wR8",
+  "createdAt": "1717000900",
+  "room": {"sid": "RM_a1b2c3", "name": "room_user-1_user-2"}
+}`
+
+// signWebhook reproduz a assinatura do LiveKit: JWT HS256 com o sha256 do corpo
+func signWebhook(t *testing.T, body, key, secret string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(body))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":    key,
+		"sha256": base64.StdEncoding.EncodeToString(sum[:]),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	})
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyWebhookAcceptsSignedFixtures(t *testing.T) {
+	lk := newTestLiveKit("wss://sfu.example.com")
+
+	event, err := lk.VerifyWebhook([]byte(participantJoinedFixture), signWebhook(t, participantJoinedFixture, testLiveKitKey, testLiveKitSecret))
+	if err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if event.Event != "participant_joined" || event.Room.Name != "room_user-1_user-2" || event.Participant.Identity != "user-2" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.Time().Unix() != 1717000000 {
+		t.Errorf("Time() = %v", event.Time())
+	}
+
+	event, err = lk.VerifyWebhook([]byte(roomFinishedFixture), "Bearer "+signWebhook(t, roomFinishedFixture, testLiveKitKey, testLiveKitSecret))
+	if err != nil {
+		t.Fatalf("VerifyWebhook with Bearer prefix: %v", err)
+	}
+	if event.Event != "room_finished" || event.Participant != nil {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestVerifyWebhookRejectsBadSignatures(t *testing.T) {
+	lk := newTestLiveKit("wss://sfu.example.com")
+	valid := signWebhook(t, participantJoinedFixture, testLiveKitKey, testLiveKitSecret)
+
+	cases := map[string]struct {
+		body string
+		auth string
+		want error
+	}{
+		"missing header": {participantJoinedFixture, "", services.ErrWebhookUnsigned},
+		"tampered body":  {roomFinishedFixture, valid, services.ErrWebhookInvalid},
+		"wrong secret":   {participantJoinedFixture, signWebhook(t, participantJoinedFixture, testLiveKitKey, "other-secret"), services.ErrWebhookInvalid},
+		"wrong api key":  {participantJoinedFixture, signWebhook(t, participantJoinedFixture, "APIother", testLiveKitSecret), services.ErrWebhookInvalid},
+		"garbage header": {participantJoinedFixture, "not-a-jwt", services.ErrWebhookInvalid},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := lk.VerifyWebhook([]byte(tc.body), tc.auth); err != tc.want {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerTracksSessionLifecycle(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	srv := serveWS(t, h)
+	bob := dialWS(t, srv, "bob", "")
+
+	sessions := &services.SessionService{DB: newTestDB(t)}
+	rtc := &controllers.RTCHandler{
+		LiveKitService: newTestLiveKit("wss://sfu.example.com"),
+		MatchService:   ms,
+		SessionService: sessions,
+		WS:             h,
+	}
+	r := gin.New()
+	r.POST("/v1/rtc/webhook", rtc.HandleWebhook)
+
+	roomID := services.NewRoomID("alice", "bob")
+	ms.RegisterRoom(roomID, "alice", "bob")
+
+	post := func(body, auth string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/rtc/webhook", strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	event := func(name, extra string) string {
+		return `{"event": "` + name + `", "createdAt": "1717000000", "room": {"name": "` + roomID + `"}` + extra + `}`
+	}
+	signed := func(body string) int { return post(body, signWebhook(t, body, testLiveKitKey, testLiveKitSecret)) }
+
+	if code := post(event("room_started", ""), "Bearer not-a-jwt"); code != http.StatusUnauthorized {
+		t.Errorf("bad signature: %d", code)
+	}
+	if code := signed(event("room_started", "")); code != http.StatusOK {
+		t.Fatalf("room_started: %d", code)
+	}
+	var session models.Session
+	if err := sessions.DB.Where("room_id = ?", roomID).First(&session).Error; err != nil || session.UserID != "alice" || session.PartnerID != "bob" {
+		t.Fatalf("session = %+v, %v", session, err)
+	}
+
+	signed(event("participant_left", `, "participant": {"identity": "alice"}`))
+	if got := bob.expect("partner_media_left"); got["partner_id"] != "alice" || got["room_id"] != roomID {
+		t.Errorf("partner_media_left = %v", got)
+	}
+
+	if code := signed(event("room_finished", "")); code != http.StatusOK {
+		t.Fatalf("room_finished: %d", code)
+	}
+	sessions.DB.Where("room_id = ?", roomID).First(&session)
+	if session.EndTime == nil || session.EndTime.Unix() != 1717000000 {
+		t.Errorf("end_time = %v", session.EndTime)
+	}
+}
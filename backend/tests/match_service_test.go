@@ -0,0 +1,314 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func newTestMatchService(t *testing.T) (*services.MatchService, *miniredis.Miniredis) {
+	t.Helper()
+	rdb, mr := newTestRedis(t)
+	return &services.MatchService{Redis: rdb}, mr
+}
+
+func TestMatchOrEnqueueNeverPairsWithSelf(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	req := services.MatchRequest{UserID: "u1", NativeLanguage: "pt", TargetLanguage: "pt"}
+
+	for i := 0; i < 2; i++ {
+		res, err := ms.MatchOrEnqueue(req)
+		if err != nil {
+			t.Fatalf("MatchOrEnqueue: %v", err)
+		}
+		if res.Status != services.MatchStatusQueued {
+			t.Fatalf("join %d: status = %s, want queued", i, res.Status)
+		}
+	}
+}
+
+func TestMatchOrEnqueuePairsInverseQueue(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+
+	res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"})
+	if res.Status != services.MatchStatusQueued {
+		t.Fatalf("first joiner: %s", res.Status)
+	}
+	res, err := ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"})
+	if err != nil || res.Status != services.MatchStatusMatched || res.Partner.UserID != "a" {
+		t.Fatalf("second joiner: %+v, %v", res, err)
+	}
+
+	if mr.Exists("queue:pt:en") || mr.Exists("match:entry:a") || mr.Exists("match:entry:b") {
+		t.Error("matched users left queue state behind")
+	}
+}
+
+func TestRemoveFromQueueDropsEveryEntry(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "es"})
+	if err := ms.RemoveFromQueue("a"); err != nil {
+		t.Fatalf("RemoveFromQueue: %v", err)
+	}
+
+	for _, key := range []string{"queue:pt:en", "queue:pt:es", "match:entry:a", "match:queues:a"} {
+		if mr.Exists(key) {
+			t.Errorf("%s still present", key)
+		}
+	}
+
+	// Um usuário que saiu não pode mais ser pareado
+	res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"})
+	if res.Status != services.MatchStatusQueued {
+		t.Errorf("matched with a user who left: %+v", res.Partner)
+	}
+}
+
+func TestRejoinWithNewPairLeavesOldQueue(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "alice", NativeLanguage: "pt", TargetLanguage: "en"})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "alice", NativeLanguage: "pt", TargetLanguage: "es"})
+	if members, _ := mr.ZMembers("queue:pt:en"); len(members) != 0 {
+		t.Errorf("queue:pt:en = %v after re-join", members)
+	}
+	if queues, _ := mr.Members("match:queues:alice"); len(queues) != 1 || queues[0] != "queue:pt:es" {
+		t.Errorf("match:queues:alice = %v", queues)
+	}
+
+	// Quem procura o par antigo não encontra alice; o novo encontra
+	res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "bob", NativeLanguage: "en", TargetLanguage: "pt"})
+	if res.Status != services.MatchStatusQueued {
+		t.Fatalf("bob matched alice through her old queue: %+v", res.Partner)
+	}
+	res, _ = ms.MatchOrEnqueue(services.MatchRequest{UserID: "carol", NativeLanguage: "es", TargetLanguage: "pt"})
+	if res.Status != services.MatchStatusMatched || res.Partner.UserID != "alice" || res.Partner.TargetLanguage != "es" {
+		t.Errorf("carol: %+v", res)
+	}
+}
+
+func TestConcurrentJoinersArePairedExactlyOnce(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	const perSide = 50
+
+	var (
+		mu      sync.Mutex
+		partner = make(map[string]string)
+		queued  = make(map[string]bool)
+		wg      sync.WaitGroup
+	)
+
+	join := func(req services.MatchRequest) {
+		defer wg.Done()
+		res, err := ms.MatchOrEnqueue(req)
+		if err != nil {
+			t.Errorf("MatchOrEnqueue(%s): %v", req.UserID, err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if res.Status == services.MatchStatusQueued {
+			queued[req.UserID] = true
+			return
+		}
+		if res.Partner.UserID == req.UserID {
+			t.Errorf("%s paired with itself", req.UserID)
+		}
+		if prev, ok := partner[res.Partner.UserID]; ok {
+			t.Errorf("%s paired twice (%s and %s)", res.Partner.UserID, prev, req.UserID)
+		}
+		partner[req.UserID] = res.Partner.UserID
+		partner[res.Partner.UserID] = req.UserID
+	}
+
+	for i := 0; i < perSide; i++ {
+		wg.Add(2)
+		go join(services.MatchRequest{UserID: fmt.Sprintf("pt-%d", i), NativeLanguage: "pt", TargetLanguage: "en"})
+		go join(services.MatchRequest{UserID: fmt.Sprintf("en-%d", i), NativeLanguage: "en", TargetLanguage: "pt"})
+	}
+	wg.Wait()
+
+	// Com lados do mesmo tamanho, quem foi enfileirado precisa ter sido escolhido depois
+	for id := range queued {
+		if _, ok := partner[id]; !ok {
+			t.Errorf("%s left waiting while the inverse queue had joiners", id)
+		}
+	}
+	if len(partner) != 2*perSide {
+		t.Errorf("paired %d users, want %d (joiners missed each other)", len(partner), 2*perSide)
+	}
+
+	ctx := context.Background()
+	left, _ := ms.Redis.ZCard(ctx, "queue:pt:en").Result()
+	right, _ := ms.Redis.ZCard(ctx, "queue:en:pt").Result()
+	if left != 0 || right != 0 {
+		t.Errorf("queues not drained: pt:en=%d en:pt=%d", left, right)
+	}
+}
+
+func TestMatchOrEnqueuePrefersBestScoredCandidate(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "oldest", NativeLanguage: "en", TargetLanguage: "pt", Interests: []string{"chess"}})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "best", NativeLanguage: "en", TargetLanguage: "pt", Interests: []string{"music", "Surf"}})
+
+	res, err := ms.MatchOrEnqueue(services.MatchRequest{UserID: "me", NativeLanguage: "pt", TargetLanguage: "en", Interests: []string{"surf", "music"}})
+	if err != nil || res.Status != services.MatchStatusMatched {
+		t.Fatalf("MatchOrEnqueue: %+v, %v", res, err)
+	}
+	if res.Partner.UserID != "best" {
+		t.Errorf("partner = %s, want best", res.Partner.UserID)
+	}
+	if len(res.CommonInterests) != 2 {
+		t.Errorf("common_interests = %v", res.CommonInterests)
+	}
+}
+
+func TestInterestScorerRewardsCrossCountryPairs(t *testing.T) {
+	me := services.MatchRequest{Country: "BR"}
+	same := services.DefaultScorer.Score(me, services.MatchRequest{Country: "br"})
+	other := services.DefaultScorer.Score(me, services.MatchRequest{Country: "JP"})
+	if other <= same {
+		t.Errorf("cross-country score %v should beat same-country %v", other, same)
+	}
+}
+
+func TestSweepRelaxesRarePairs(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	ctx := context.Background()
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "fi-user", NativeLanguage: "fi", TargetLanguage: "ko", SpokenLanguages: []string{"en"}})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "ko-user", NativeLanguage: "ko", TargetLanguage: "en"})
+
+	// Sem política, ninguém é pareado
+	events, err := ms.Sweep(ctx)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("strict sweep: %v, %v", events, err)
+	}
+
+	ms.Relaxation = &services.RelaxationPolicy{SpokenAfter: time.Nanosecond}
+	events, err = ms.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	var pair *services.MatchPair
+	updates := 0
+	for _, e := range events {
+		if e.Pair != nil {
+			pair = e.Pair
+		}
+		if e.Update != nil {
+			updates++
+			if e.Update.RelaxLevel != services.RelaxSpoken || e.UserID == "" {
+				t.Errorf("unexpected update: %+v", e)
+			}
+		}
+	}
+	if updates == 0 {
+		t.Error("no queue_update emitted for the relaxation step")
+	}
+	if pair == nil {
+		t.Fatal("relaxed sweep did not pair fi->ko with ko->en")
+	}
+	if mode := services.MatchMode(pair.User, pair.Partner); mode != services.MatchModeRelaxed {
+		t.Errorf("mode = %s, want relaxed", mode)
+	}
+}
+
+type blockList map[string][]string
+
+func (b blockList) Exclusions(userID string) ([]string, error) { return b[userID], nil }
+
+func TestBlockedUsersAreNeverPaired(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	// Só "b" registrou o bloqueio; o lado de "a" precisa ser checado no script
+	ms.Blocks = blockList{"b": {"a"}}
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"})
+	res, err := ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"})
+	if err != nil || res.Status != services.MatchStatusQueued {
+		t.Fatalf("blocked pair matched: %+v, %v", res, err)
+	}
+
+	// Um terceiro usuário continua podendo parear com os dois
+	res, err = ms.MatchOrEnqueue(services.MatchRequest{UserID: "c", NativeLanguage: "en", TargetLanguage: "pt"})
+	if err != nil || res.Status != services.MatchStatusMatched || res.Partner.UserID != "a" {
+		t.Fatalf("third joiner: %+v, %v", res, err)
+	}
+}
+
+func TestAddExclusionAppliesToQueuedUsers(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"})
+	if err := ms.AddExclusion("b", "a"); err != nil {
+		t.Fatalf("AddExclusion: %v", err)
+	}
+	res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"})
+	if res.Status != services.MatchStatusQueued {
+		t.Errorf("matched right after block: %+v", res.Partner)
+	}
+}
+
+func TestRecentPartnersWaitOutCooldown(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+	ms.RecentCooldown = time.Minute
+
+	a := services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"}
+	b := services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"}
+	ms.MatchOrEnqueue(a)
+	first, _ := ms.MatchOrEnqueue(b)
+	if first.Status != services.MatchStatusMatched {
+		t.Fatalf("first pairing: %s", first.Status)
+	}
+	ms.ReleaseRoom(first.RoomID, "b", "a")
+
+	// "next": os dois voltam para a fila e ficam esperando alguém novo
+	ms.MatchOrEnqueue(a)
+	res, err := ms.MatchOrEnqueue(b)
+	if err != nil || res.Status != services.MatchStatusQueued || !res.Cooldown {
+		t.Fatalf("rematched during cooldown: %+v, %v", res, err)
+	}
+
+	res, _ = ms.MatchOrEnqueue(services.MatchRequest{UserID: "c", NativeLanguage: "en", TargetLanguage: "pt"})
+	if res.Status != services.MatchStatusMatched || res.Partner.UserID != "a" {
+		t.Fatalf("new partner not matched: %+v", res)
+	}
+
+	// "b" continua na fila; passado o cooldown, "a" volta a poder encontrá-lo
+	mr.FastForward(2 * time.Minute)
+	if res, _ := ms.MatchOrEnqueue(a); res.Status != services.MatchStatusMatched || res.Partner.UserID != "b" {
+		t.Errorf("still blocked after cooldown: %+v", res)
+	}
+}
+
+func TestRematchedPairGetsNewRoom(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	a := services.MatchRequest{UserID: "a", NativeLanguage: "pt", TargetLanguage: "en"}
+	b := services.MatchRequest{UserID: "b", NativeLanguage: "en", TargetLanguage: "pt"}
+
+	var rooms []string
+	for i := 0; i < 2; i++ {
+		ms.MatchOrEnqueue(a)
+		res, err := ms.MatchOrEnqueue(b)
+		if err != nil || res.Status != services.MatchStatusMatched {
+			t.Fatalf("pairing %d: %+v, %v", i, res, err)
+		}
+		if state, _ := ms.Status(context.Background(), "a"); state == nil || state.RoomID != res.RoomID {
+			t.Errorf("status room = %+v, want %s", state, res.RoomID)
+		}
+		rooms = append(rooms, res.RoomID)
+		ms.ReleaseRoom(res.RoomID, "b", "a")
+	}
+	if rooms[0] == rooms[1] {
+		t.Errorf("same pair reused room %s", rooms[0])
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestStatusReportsPositionAndETA(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+	ctx := context.Background()
+
+	if state, err := ms.Status(ctx, "nobody"); err != nil || state != nil {
+		t.Fatalf("unknown user: %+v, %v", state, err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		ms.MatchOrEnqueue(services.MatchRequest{UserID: id, NativeLanguage: "pt", TargetLanguage: "en"})
+	}
+
+	// Sem histórico de vazão não há estimativa
+	state, err := ms.Status(ctx, "c")
+	if err != nil || state.Status != services.MatchStateSearching || state.Position != 3 || state.ETASeconds != nil {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+
+	// 11 pareamentos em 10s: um por segundo, então a 3ª posição espera ~3s
+	now := time.Now().UnixMilli()
+	for i := 10; i >= 0; i-- {
+		mr.Lpush("match:throughput:queue:pt:en", strconv.FormatInt(now-int64(i)*1000, 10))
+	}
+	state, _ = ms.Status(ctx, "c")
+	if state.ETASeconds == nil || *state.ETASeconds != 3 {
+		t.Errorf("eta = %v, want 3", state.ETASeconds)
+	}
+
+	res, _ := ms.MatchOrEnqueue(services.MatchRequest{UserID: "d", NativeLanguage: "en", TargetLanguage: "pt"})
+	state, _ = ms.Status(ctx, res.Partner.UserID)
+	if state.Status != services.MatchStateMatched || state.RoomID != res.RoomID || state.PartnerID != "d" || state.PartnerLanguage != "en" {
+		t.Errorf("matched state = %+v", state)
+	}
+}
+
+func TestRESTJoinOpensRoomWithWebSocketPartner(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	bob := dialWS(t, serveWS(t, h), "bob", "")
+	bob.send("join_queue", services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+	bob.expect("queue_joined")
+
+	rest := &controllers.NexusHandler{MatchService: ms, WS: h}
+	r := gin.New()
+	r.POST("/v1/match/join", func(c *gin.Context) { c.Set("user_id", "alice") }, rest.HandleJoinQueue)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/match/join",
+		strings.NewReader(`{"native_language": "pt", "target_language": "en"}`)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"connected"`) {
+		t.Fatalf("join: %d %s", w.Code, w.Body)
+	}
+
+	matched := bob.expect("matched")
+	roomID := matched["room_id"].(string)
+	if partner := matched["partner"].(map[string]interface{}); partner["id"] != "alice" {
+		t.Errorf("matched = %v", matched)
+	}
+	if current, _ := ms.CurrentRoom("alice"); current != roomID {
+		t.Errorf("alice room = %q, want %q", current, roomID)
+	}
+
+	// A sala é liberada quando o parceiro sai
+	bob.conn.Close()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if current, _ := ms.CurrentRoom("alice"); current == "" {
+			return
+		}
+	}
+	t.Error("room not released after the WebSocket partner left")
+}
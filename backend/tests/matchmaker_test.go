@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestLeadershipIsExclusiveUntilItExpires(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		instance string
+		want     bool
+	}{{"a", true}, {"b", false}, {"a", true}} {
+		got, err := ms.AcquireLeadership(ctx, tc.instance, time.Second)
+		if err != nil || got != tc.want {
+			t.Fatalf("AcquireLeadership(%s) = %v, %v; want %v", tc.instance, got, err, tc.want)
+		}
+	}
+
+	// A líder parou de renovar: outra instância assume
+	mr.FastForward(2 * time.Second)
+	if got, _ := ms.AcquireLeadership(ctx, "b", time.Second); !got {
+		t.Fatal("leadership not taken over after expiry")
+	}
+	if got, _ := ms.AcquireLeadership(ctx, "a", time.Second); got {
+		t.Error("former leader still acquires")
+	}
+}
+
+func TestSweepRecordsMatchedState(t *testing.T) {
+	ms, mr := newTestMatchService(t)
+	ms.Relaxation = &services.RelaxationPolicy{SpokenAfter: time.Nanosecond}
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "fi-user", NativeLanguage: "fi", TargetLanguage: "ko", SpokenLanguages: []string{"en"}})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "ko-user", NativeLanguage: "ko", TargetLanguage: "en"})
+
+	events, err := ms.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	var pair *services.MatchPair
+	for _, e := range events {
+		if e.Pair != nil {
+			pair = e.Pair
+		}
+	}
+	if pair == nil || pair.RoomID == "" {
+		t.Fatalf("no pair in %+v", events)
+	}
+	for _, id := range []string{"fi-user", "ko-user"} {
+		state, _ := ms.Status(context.Background(), id)
+		if state == nil || state.Status != services.MatchStateMatched || state.RoomID != pair.RoomID {
+			t.Errorf("%s state = %+v", id, state)
+		}
+	}
+	if mr.Exists("queue:fi:ko") || mr.Exists("queue:ko:en") {
+		t.Error("swept users left in the queues")
+	}
+}
+
+// newTestCluster sobe n instâncias do WSHandler no mesmo Redis, com matchmaker e relay rodando
+func newTestCluster(t *testing.T, n int) ([]*controllers.WSHandler, *services.MatchService) {
+	t.Helper()
+	rdb, mr := newTestRedis(t)
+
+	var handlers []*controllers.WSHandler
+	for i := 0; i < n; i++ {
+		h := controllers.NewWSHandler(nil, &services.MatchService{Redis: rdb}, &services.AuthService{JWTSecret: []byte(testJWTSecret)})
+		h.GroupService = &services.GroupService{Redis: rdb, Capacity: 3}
+		handlers = append(handlers, h)
+	}
+	// Registrado depois dos clientes, então as assinaturas param antes de eles fecharem
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	for _, h := range handlers {
+		go h.RunMatchmaker(ctx, time.Hour)
+		go h.RunRelay(ctx)
+	}
+	// Espera as assinaturas de eventos e relay de todas as instâncias
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if mr.PubSubNumSub("match:events")["match:events"] == n && mr.PubSubNumSub("ws:relay")["ws:relay"] == n {
+			break
+		}
+	}
+	return handlers, handlers[0].MatchService
+}
+
+func TestMatchEventOpensRoomOnlyOnce(t *testing.T) {
+	hs, ms := newTestCluster(t, 2)
+	alice := dialWS(t, serveWS(t, hs[0]), "alice", "")
+	bob := dialWS(t, serveWS(t, hs[1]), "bob", "")
+
+	roomID := services.NewRoomID("alice", "bob")
+	ms.PublishEvent(context.Background(), services.MatchEvent{Pair: &services.MatchPair{
+		User:    services.MatchRequest{UserID: "alice", NativeLanguage: "pt", TargetLanguage: "en"},
+		Partner: services.MatchRequest{UserID: "bob", NativeLanguage: "en", TargetLanguage: "pt"},
+		RoomID:  roomID,
+	}})
+
+	for _, c := range []*wsClient{alice, bob} {
+		if got := c.expect("matched"); got["room_id"] != roomID {
+			t.Errorf("matched = %v", got)
+		}
+		c.expectNone("matched", 200*time.Millisecond)
+	}
+	owner, _ := ms.RoomOwner(roomID)
+	if owner != hs[0].InstanceID && owner != hs[1].InstanceID {
+		t.Errorf("room owner = %q", owner)
+	}
+}
+
+func TestRoomTrafficCrossesInstances(t *testing.T) {
+	hs, _ := newTestCluster(t, 2)
+	alice := dialWS(t, serveWS(t, hs[0]), "alice", "")
+	bob := dialWS(t, serveWS(t, hs[1]), "bob", "")
+	// bob completa o par na instância 1, que fica dona da sala
+	pairClients(t, alice, bob)
+
+	alice.send("webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "offer", "sdp": testSDP}})
+	bob.expect("webrtc_offer")
+	bob.send("webrtc_answer", map[string]interface{}{"sdp": map[string]string{"type": "answer", "sdp": testSDP}})
+	alice.expect("webrtc_answer")
+
+	alice.send("chat_message", map[string]string{"text": "oi"})
+	if got := bob.expect("chat_message"); got["text"] != "oi" {
+		t.Errorf("chat_message = %v", got)
+	}
+
+	alice.conn.Close()
+	if got := bob.expect("partner_left"); got["reason"] != "disconnected" {
+		t.Errorf("partner_left = %v", got)
+	}
+}
+
+func TestBotGetsOneSessionPerRoom(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	ms.Relaxation = &services.RelaxationPolicy{OneWayAfter: time.Nanosecond, BotUserID: "bot"}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go h.RunMatchmaker(ctx, 20*time.Millisecond)
+
+	srv := serveWS(t, h)
+	bot := dialWS(t, srv, "bot", "")
+	alice, carol := dialWS(t, srv, "alice", ""), dialWS(t, srv, "carol", "")
+	alice.send("join_queue", services.MatchRequest{NativeLanguage: "pt", TargetLanguage: "fi"})
+	carol.send("join_queue", services.MatchRequest{NativeLanguage: "es", TargetLanguage: "fi"})
+
+	sessions := map[string]string{}
+	for _, c := range []*wsClient{alice, carol} {
+		matched := c.expect("matched")
+		partner := matched["partner"].(map[string]interface{})
+		if partner["bot"] != true || services.BotSessionOwner(partner["id"].(string)) != "bot" {
+			t.Fatalf("matched = %v", matched)
+		}
+		sessions[partner["id"].(string)] = matched["room_id"].(string)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("bot sessions = %v, want one per room", sessions)
+	}
+	for session, roomID := range sessions {
+		if current, _ := ms.CurrentRoom(session); current != roomID {
+			t.Errorf("room of %s = %q, want %q", session, current, roomID)
+		}
+	}
+
+	// O bot responde em cada sala pela sessão certa
+	for i := 0; i < 2; i++ {
+		msg := bot.expectMessage("matched")
+		if _, ok := sessions[msg.Session]; !ok {
+			t.Fatalf("bot matched for unknown session %q", msg.Session)
+		}
+	}
+	alice.send("chat_message", map[string]string{"text": "oi"})
+	toBot := bot.expectMessage("chat_message")
+	if final := bot.expectMessage("chat_message"); final.Session != toBot.Session {
+		t.Errorf("final message for session %q, original for %q", final.Session, toBot.Session)
+	}
+	bot.sendAs(toBot.Session, "chat_message", map[string]string{"text": "hei"})
+	if got := alice.expect("chat_message"); got["text"] != "hei" {
+		t.Errorf("alice got %v", got)
+	}
+	carol.expectNone("chat_message", 100*time.Millisecond)
+
+	// Uma sessão alheia é ignorada, e o bot não inventa sessões
+	alice.sendAs(toBot.Session, "chat_message", map[string]string{"text": "spoof"})
+	bot.expectNone("chat_message", 100*time.Millisecond)
+	bot.sendAs("bot#forged", "join_queue", services.MatchRequest{NativeLanguage: "fi", TargetLanguage: "pt"})
+	bot.expectNone("queue_joined", 100*time.Millisecond)
+
+	bot.conn.Close()
+	alice.expect("partner_left")
+	carol.expect("partner_left")
+}
+
+func TestUserCannotActAsSession(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	ms.Relaxation = &services.RelaxationPolicy{BotUserID: "bot"}
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+
+	// Uma identidade nova por "<id>#..." passaria por bloqueios e cooldowns
+	alice.send("join_queue", services.MatchRequest{NativeLanguage: "pt", TargetLanguage: "en"})
+	alice.expect("queue_joined")
+	bob.sendAs("bob#sock", "join_queue", services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+	bob.expectNone("queue_joined", 100*time.Millisecond)
+	alice.expectNone("matched", 100*time.Millisecond)
+	if n, _ := ms.Redis.Exists(context.Background(), "match:entry:bob#sock").Result(); n != 0 {
+		t.Error("forged session was queued")
+	}
+}
+
+func TestGroupTrafficCrossesInstances(t *testing.T) {
+	hs, ms := newTestCluster(t, 2)
+	srvs := []*httptest.Server{serveWS(t, hs[0]), serveWS(t, hs[1])}
+	join := map[string]string{"target_lang": "en", "native_lang": "pt", "level": "b1"}
+
+	u1 := dialWS(t, srvs[0], "u1", "")
+	u1.send("join_group", join)
+	u1.expect("group_joined")
+	u2 := dialWS(t, srvs[1], "u2", "")
+	u2.send("join_group", join)
+	u2.expect("group_joined")
+	u1.expect("group_member_joined")
+	u3 := dialWS(t, srvs[1], "u3", "")
+	u3.send("join_group", join)
+	groupID := u3.expect("group_joined")["room_id"].(string)
+	u2.expect("group_member_joined")
+
+	// u1 fala pela instância 0, cuja cópia da mesa não viu u2 e u3 entrarem
+	u1.send("chat_message", map[string]string{"text": "olá"})
+	for _, c := range []*wsClient{u2, u3} {
+		if got := c.expect("chat_message"); got["text"] != "olá" {
+			t.Errorf("chat_message = %v", got)
+		}
+	}
+
+	u2.send("leave_group", nil)
+	u2.expect("group_left")
+	if got := u1.expect("group_member_left"); got["user_id"] != "u2" {
+		t.Errorf("group_member_left = %v", got)
+	}
+	members, _ := ms.RoomMembers(groupID)
+	if len(members) != 2 || members[0] == "u2" || members[1] == "u2" {
+		t.Errorf("room members = %v", members)
+	}
+}
+
+func TestNextRequeuesBothWithoutRematch(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	ms.RecentCooldown = time.Minute
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	roomID := pairClients(t, alice, bob)
+
+	alice.send("next", nil)
+	if got := bob.expect("partner_left"); got["reason"] != "next" || got["room_id"] != roomID {
+		t.Errorf("partner_left = %v", got)
+	}
+	bob.expect("queue_joined")
+	// alice entra depois de bob e encontra só ele na fila: o cooldown segura o par
+	if got := alice.expect("queue_joined"); got["reason"] != "recent_partner_cooldown" {
+		t.Errorf("queue_joined = %v", got)
+	}
+	alice.expectNone("matched", 100*time.Millisecond)
+	bob.expectNone("matched", 50*time.Millisecond)
+	for _, id := range []string{"alice", "bob"} {
+		if current, _ := ms.CurrentRoom(id); current != "" {
+			t.Errorf("%s still in room %s", id, current)
+		}
+		if state, _ := ms.Status(context.Background(), id); state == nil || state.Status != services.MatchStateSearching {
+			t.Errorf("%s state = %+v", id, state)
+		}
+	}
+
+	// Alguém novo é pareado normalmente, numa sala nova
+	carol := dialWS(t, srv, "carol", "")
+	carol.send("join_queue", services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+	matched := alice.expect("matched")
+	if partner := matched["partner"].(map[string]interface{}); partner["id"] != "carol" || matched["room_id"] == roomID {
+		t.Errorf("matched = %v", matched)
+	}
+	bob.expectNone("matched", 50*time.Millisecond)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func newTestRatingService(t *testing.T) (*services.RatingService, *services.SessionService) {
+	t.Helper()
+	db := newTestDB(t)
+	return &services.RatingService{DB: db, Reputation: &services.ReputationService{DB: db}}, &services.SessionService{DB: db}
+}
+
+func TestRateOncePerSession(t *testing.T) {
+	rs, sessions := newTestRatingService(t)
+	first, _ := sessions.Start(services.NewRoomID("a", "b"), time.Now(), "a", "b")
+
+	rating, err := rs.Rate(first.ID, "a", services.RatingInput{Score: 5, Tags: []string{"Patient", "patient", " "}})
+	if err != nil {
+		t.Fatalf("Rate: %v", err)
+	}
+	if rating.RatedUserID != "b" || len(rating.Tags) != 1 || rating.Tags[0] != "patient" {
+		t.Errorf("rating = %+v", rating)
+	}
+	if _, err := rs.Rate(first.ID, "a", services.RatingInput{Score: 4}); !errors.Is(err, services.ErrAlreadyRated) {
+		t.Errorf("duplicate rating: %v", err)
+	}
+	if _, err := rs.Rate(first.ID, "b", services.RatingInput{Score: 4}); err != nil {
+		t.Errorf("partner rating: %v", err)
+	}
+
+	// A mesma dupla numa conversa nova pode se avaliar de novo
+	second, _ := sessions.Start(services.NewRoomID("a", "b"), time.Now(), "a", "b")
+	if _, err := rs.Rate(second.ID, "a", services.RatingInput{Score: 3}); err != nil {
+		t.Errorf("rating a second conversation: %v", err)
+	}
+	if stats, _ := rs.Stats("b"); stats.Count != 2 || stats.Average != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRateRejectsOutsiders(t *testing.T) {
+	rs, sessions := newTestRatingService(t)
+	session, _ := sessions.Start("room_a_b", time.Now(), "a", "b")
+
+	cases := map[string]struct {
+		sessionID, rater string
+		score            int
+		want             error
+	}{
+		"not a participant": {session.ID, "c", 5, services.ErrNotParticipant},
+		"unknown session":   {"missing", "a", 5, services.ErrSessionNotFound},
+		"score too high":    {session.ID, "a", 6, services.ErrInvalidRating},
+	}
+	for name, tc := range cases {
+		if _, err := rs.Rate(tc.sessionID, tc.rater, services.RatingInput{Score: tc.score}); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+	if _, err := rs.RateRoom("room_a_b", "b", services.RatingInput{Score: 2}); err != nil {
+		t.Errorf("RateRoom: %v", err)
+	}
+}
+
+func TestRateTruncatesTextByCharacters(t *testing.T) {
+	rs, sessions := newTestRatingService(t)
+	session, _ := sessions.Start("room_a_b", time.Now(), "a", "b")
+
+	rating, err := rs.Rate(session.ID, "a", services.RatingInput{Score: 5, Text: strings.Repeat("ã", 600)})
+	if err != nil {
+		t.Fatalf("Rate: %v", err)
+	}
+	if !utf8.ValidString(rating.Text) || utf8.RuneCountInString(rating.Text) != 500 {
+		t.Errorf("text has %d runes (valid utf-8: %v)", utf8.RuneCountInString(rating.Text), utf8.ValidString(rating.Text))
+	}
+}
+
+func TestRateSessionHandlerValidatesID(t *testing.T) {
+	rs, sessions := newTestRatingService(t)
+	session, _ := sessions.Start("room_a_b", time.Now(), "a", "b")
+	handler := &controllers.RatingHandler{RatingService: rs}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/sessions/:id/rating", func(c *gin.Context) { c.Set("user_id", "a") }, handler.HandleRateSession)
+	rate := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/sessions/"+id+"/rating", strings.NewReader(`{"score": 4}`)))
+		return w
+	}
+
+	for _, tc := range []struct {
+		id      string
+		code    int
+		errCode string
+	}{
+		{"not-a-uuid", http.StatusBadRequest, "invalid_session_id"},
+		{"room_a_b", http.StatusBadRequest, "invalid_session_id"},
+		{uuid.NewString(), http.StatusNotFound, "session_not_found"},
+		{session.ID, http.StatusCreated, ""},
+		{session.ID, http.StatusConflict, "already_rated"},
+	} {
+		if w := rate(tc.id); w.Code != tc.code || !strings.Contains(w.Body.String(), tc.errCode) {
+			t.Errorf("%s: %d %s", tc.id, w.Code, w.Body)
+		}
+	}
+}
+
+func TestGroupRoomsAreNotRated(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	h.GroupService = &services.GroupService{Redis: ms.Redis, Capacity: 3}
+	h.RatingService, _ = newTestRatingService(t)
+	srv := serveWS(t, h)
+
+	u1, u2 := dialWS(t, srv, "u1", ""), dialWS(t, srv, "u2", "")
+	for _, c := range []*wsClient{u1, u2} {
+		c.send("join_group", map[string]string{"target_lang": "en", "native_lang": "pt", "level": "b1"})
+		c.expect("group_joined")
+	}
+	u1.send("rate_partner", map[string]int{"score": 5})
+	if got := u1.expect("rating_error"); got["error"] != "group_not_rated" {
+		t.Errorf("rating_error = %v", got)
+	}
+	u1.send("rate_partner", map[string]interface{}{"score": 5, "session_id": "room_a_b"})
+	if got := u1.expect("rating_error"); got["error"] != "invalid_session_id" {
+		t.Errorf("rating_error = %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestDecayedReputationHalvesOldAdjustments(t *testing.T) {
+	now := time.Now()
+	halfLife := 30 * 24 * time.Hour
+	events := []models.ReputationEvent{
+		{Delta: -20, CreatedAt: now.Add(-halfLife)},
+		{Delta: 1, CreatedAt: now},
+	}
+
+	if got := services.DecayedReputation(events, now, halfLife); got < 90.99 || got > 91.01 {
+		t.Errorf("reputation = %.2f, want 91", got)
+	}
+	if got := services.DecayedReputation(nil, now, halfLife); got != services.DefaultReputation {
+		t.Errorf("empty ledger = %.2f, want %.0f", got, services.DefaultReputation)
+	}
+
+	floor := make([]models.ReputationEvent, 10)
+	for i := range floor {
+		floor[i] = models.ReputationEvent{Delta: -20, CreatedAt: now}
+	}
+	if got := services.DecayedReputation(floor, now, halfLife); got != 0 {
+		t.Errorf("reputation below zero: %.2f", got)
+	}
+}
+
+type reputationTable map[string]float64
+
+func (r reputationTable) Reputation(userID string) (float64, error) {
+	if rep, ok := r[userID]; ok {
+		return rep, nil
+	}
+	return services.DefaultReputation, nil
+}
+
+func TestMatchOrEnqueueKeepsReputationBandsTogether(t *testing.T) {
+	ms, _ := newTestMatchService(t)
+	ms.Reputation = reputationTable{"offender": 30, "other-offender": 20}
+
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "newcomer", NativeLanguage: "en", TargetLanguage: "pt"})
+	ms.MatchOrEnqueue(services.MatchRequest{UserID: "other-offender", NativeLanguage: "en", TargetLanguage: "pt"})
+
+	res, err := ms.MatchOrEnqueue(services.MatchRequest{UserID: "offender", NativeLanguage: "pt", TargetLanguage: "en", Reputation: 150})
+	if err != nil || res.Status != services.MatchStatusMatched {
+		t.Fatalf("MatchOrEnqueue: %+v, %v", res, err)
+	}
+	if res.Partner.UserID != "other-offender" {
+		t.Errorf("partner = %s, want other-offender", res.Partner.UserID)
+	}
+}
+
+func TestReputationWritesOnlyWhenScoreChanges(t *testing.T) {
+	db := newTestDB(t)
+	rs := &services.ReputationService{DB: db}
+	user := models.User{AnonymousID: "anon-1"}
+	db.Create(&user)
+
+	if _, err := rs.Record(user.ID, services.ReputationEarlyDisconnect, "room-1"); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", user.ID)
+	if stored.Reputation < 97.9 || stored.Reputation > 98.1 {
+		t.Fatalf("reputation = %v, want ~98", stored.Reputation)
+	}
+
+	// Leituras sem ajuste novo não tocam a linha
+	db.Model(&stored).UpdateColumn("updated_at", time.Unix(0, 0))
+	rs.Reputation(user.ID)
+	rs.Record(user.ID, services.ReputationEarlyDisconnect, "room-1")
+	db.First(&stored, "id = ?", user.ID)
+	if !stored.UpdatedAt.Equal(time.Unix(0, 0)) {
+		t.Errorf("users row rewritten without a score change (updated_at %v)", stored.UpdatedAt)
+	}
+
+	rs.Record(user.ID, services.ReputationPositiveRating, "rating-1")
+	db.First(&stored, "id = ?", user.ID)
+	if stored.Reputation < 100.9 || stored.Reputation > 101.1 {
+		t.Errorf("reputation after rating = %v, want ~101", stored.Reputation)
+	}
+}
+
+func TestReportStoresEmptyJSONEvidence(t *testing.T) {
+	rs := &services.ReputationService{DB: newTestDB(t)}
+
+	report, err := rs.Report("a", "b", "spam", "")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	var stored models.Report
+	rs.DB.First(&stored, "id = ?", report.ID)
+	if stored.AiEvidence != "{}" {
+		t.Errorf("ai_evidence = %q, want {}", stored.AiEvidence)
+	}
+	if again, _ := rs.Report("a", "b", "spam", ""); again.ID != report.ID {
+		t.Error("repeated report inside the cooldown created a new one")
+	}
+}
+
+func TestReportWeighsOnlyOnceUpheld(t *testing.T) {
+	rs := &services.ReputationService{DB: newTestDB(t)}
+
+	report, err := rs.Report("a", "b", "spam", "")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if rep, _ := rs.Reputation("b"); rep != services.DefaultReputation {
+		t.Errorf("reputation before uphold = %.2f", rep)
+	}
+
+	// Confirmar duas vezes não pesa de novo
+	for i := 0; i < 2; i++ {
+		if _, err := rs.UpholdReport(report.ID); err != nil {
+			t.Fatalf("UpholdReport: %v", err)
+		}
+	}
+	if rep, _ := rs.Reputation("b"); rep < 74.9 || rep > 75.1 {
+		t.Errorf("reputation after uphold = %.2f, want ~75", rep)
+	}
+	if _, err := rs.UpholdReport("missing"); err != services.ErrReportNotFound {
+		t.Errorf("unknown report = %v", err)
+	}
+}
+
+func TestReportOnlyReachesPartners(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	ms.RecentCooldown = time.Minute
+	db := newTestDB(t)
+	h.ReputationService = &services.ReputationService{DB: db}
+	h.SessionService = &services.SessionService{DB: db}
+
+	srv := serveWS(t, h)
+	alice, bob, carol := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", ""), dialWS(t, srv, "carol", "")
+	pairClients(t, alice, bob)
+
+	report := func(c *wsClient, target, want string) map[string]interface{} {
+		c.t.Helper()
+		c.send("report_user", map[string]string{"user_id": target, "reason": "spam"})
+		return c.expect(want)
+	}
+
+	// Quem nunca esteve na sala não pode ser denunciado
+	if got := report(alice, "carol", "report_error"); got["error"] != "not_a_partner" {
+		t.Errorf("report of a stranger = %v", got)
+	}
+	report(carol, "alice", "report_error")
+	report(alice, "bob", "report_submitted")
+
+	// Depois da conversa, o cooldown e a sessão gravada ainda ligam os dois
+	bob.send("next", nil)
+	alice.expect("partner_left")
+	report(bob, "alice", "report_submitted")
+	ms.Redis.FlushAll(context.Background())
+	report(alice, "bob", "report_submitted")
+
+	var reports int64
+	db.Model(&models.Report{}).Count(&reports)
+	if reports != 2 {
+		t.Errorf("reports = %d, want 2 (alice's second one is inside the cooldown)", reports)
+	}
+}
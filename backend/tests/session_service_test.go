@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/models"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestSessionStartIsIdempotentAndEndIsFinal(t *testing.T) {
+	s := &services.SessionService{DB: newTestDB(t)}
+	start := time.Now().Add(-10 * time.Minute)
+
+	first, err := s.Start("room_a_b_1", start, "a", "b")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if first.UserID != "a" || first.PartnerID != "b" {
+		t.Errorf("participants = %s, %s", first.UserID, first.PartnerID)
+	}
+	again, err := s.Start("room_a_b_1", time.Now(), "a", "b")
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("second Start created %+v, %v", again, err)
+	}
+
+	end := time.Now()
+	if err := s.End("room_a_b_1", end); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	s.End("room_a_b_1", end.Add(time.Hour))
+
+	// Um Start atrasado (ex.: webhook fora de ordem) não reabre a sessão
+	if _, err := s.Start("room_a_b_1", time.Now(), "a", "b"); err != nil {
+		t.Fatalf("Start after End: %v", err)
+	}
+	var stored models.Session
+	s.DB.Where("room_id = ?", "room_a_b_1").First(&stored)
+	if stored.EndTime == nil || !stored.EndTime.Equal(end) {
+		t.Errorf("end_time = %v, want %v", stored.EndTime, end)
+	}
+}
+
+func TestSessionJoinFillsPartner(t *testing.T) {
+	s := &services.SessionService{DB: newTestDB(t)}
+
+	if _, err := s.Start("room_x", time.Now()); err == nil {
+		t.Error("Start without participants should fail")
+	}
+	if _, err := s.Join("room_x", "a", time.Now()); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	s.Join("room_x", "a", time.Now())
+	session, err := s.Join("room_x", "b", time.Now())
+	if err != nil || session.UserID != "a" || session.PartnerID != "b" {
+		t.Fatalf("session = %+v, %v", session, err)
+	}
+	if third, _ := s.Join("room_x", "c", time.Now()); third.PartnerID != "b" {
+		t.Errorf("third participant replaced partner: %+v", third)
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSDP = "v=0\r\no=- 46117317 2 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\nm=audio 9 UDP/TLS/RTP/SAVPF 111\r\n"
+
+func TestSignalingRelaysOnlyToPartner(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	srv := serveWS(t, h)
+	a, b := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	outsider := dialWS(t, srv, "carol", "")
+	pairClients(t, a, b)
+
+	a.send("webrtc_offer", map[string]interface{}{
+		"sdp":   map[string]string{"type": "offer", "sdp": testSDP},
+		"extra": "dropped",
+	})
+	offer := b.expect("webrtc_offer")
+	if sdp := offer["sdp"].(map[string]interface{}); sdp["sdp"] != testSDP || sdp["type"] != "offer" {
+		t.Errorf("relayed sdp = %v", sdp)
+	}
+	if _, ok := offer["extra"]; ok {
+		t.Error("unknown fields relayed to the partner")
+	}
+
+	b.send("webrtc_ice", map[string]interface{}{
+		"candidate": map[string]interface{}{"candidate": "candidate:1 1 udp 2122260223 10.0.0.2 54321 typ host", "sdpMid": "0", "sdpMLineIndex": 0},
+	})
+	a.expect("webrtc_ice")
+	outsider.expectNone("webrtc_offer", 100*time.Millisecond)
+}
+
+func TestSignalingRejectsMalformedPayloads(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	srv := serveWS(t, h)
+	a, b := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, a, b)
+
+	cases := []struct {
+		name, msgType string
+		payload       interface{}
+		want          string
+	}{
+		{"missing sdp", "webrtc_offer", map[string]string{}, "malformed_payload"},
+		{"type mismatch", "webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "answer", "sdp": testSDP}}, "sdp_type_mismatch"},
+		{"not an sdp", "webrtc_answer", map[string]interface{}{"sdp": map[string]string{"type": "answer", "sdp": "hello"}}, "invalid_sdp"},
+		{"oversized sdp", "webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "offer", "sdp": testSDP + strings.Repeat("a", 64*1024)}}, "payload_too_large"},
+		{"bad candidate", "webrtc_ice", map[string]interface{}{"candidate": map[string]interface{}{"candidate": "host 10.0.0.2", "sdpMid": "0"}}, "invalid_candidate"},
+		{"candidate without mid", "webrtc_ice", map[string]interface{}{"candidate": map[string]interface{}{"candidate": "candidate:1 1 udp 1 10.0.0.2 1 typ host"}}, "invalid_candidate"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a.send(tc.msgType, tc.payload)
+			got := a.expect("signal_error")
+			if got["error"] != tc.want || got["type"] != tc.msgType {
+				t.Errorf("signal_error = %v, want %s", got, tc.want)
+			}
+		})
+	}
+	b.expectNone("webrtc_offer", 100*time.Millisecond)
+}
+
+func TestICERestartIsBoundedAndResetByAnswer(t *testing.T) {
+	h, _ := newTestWSHandler(t)
+	srv := serveWS(t, h)
+	a, b := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, a, b)
+
+	// bob entrou por último, então é o primeiro membro da sala e gera a nova offer
+	for attempt := 1.0; attempt <= 3; attempt++ {
+		a.send("ice_failure", map[string]string{"reason": "disconnected"})
+		if got := a.expect("ice_restart"); got["attempt"] != attempt || got["initiator"] != false {
+			t.Fatalf("alice ice_restart = %v", got)
+		}
+		if got := b.expect("ice_restart"); got["attempt"] != attempt || got["initiator"] != true {
+			t.Fatalf("bob ice_restart = %v", got)
+		}
+	}
+	a.send("ice_failure", nil)
+	if got := b.expect("ice_failed"); got["attempts"] != 3.0 {
+		t.Errorf("ice_failed = %v", got)
+	}
+	a.expect("ice_failed")
+
+	// Uma negociação concluída devolve todas as tentativas
+	b.send("webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "offer", "sdp": testSDP}})
+	a.expect("webrtc_offer")
+	a.send("webrtc_answer", map[string]interface{}{"sdp": map[string]string{"type": "answer", "sdp": testSDP}})
+	b.expect("webrtc_answer")
+
+	a.send("ice_failure", nil)
+	if got := b.expect("ice_restart"); got["attempt"] != 1.0 {
+		t.Errorf("attempt after answer = %v, want 1", got["attempt"])
+	}
+}
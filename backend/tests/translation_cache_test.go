@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type countingTranslator struct {
+	services.FakeTranslator
+	calls int
+}
+
+func (c *countingTranslator) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	c.calls++
+	return c.FakeTranslator.Translate(ctx, req)
+}
+
+func newTestCache(t *testing.T, maxEntries int64) (*services.CachedTranslator, *countingTranslator) {
+	t.Helper()
+	rdb, _ := newTestRedis(t)
+	next := &countingTranslator{}
+	return &services.CachedTranslator{Next: next, Redis: rdb, MaxEntries: maxEntries}, next
+}
+
+func TestTranslationCacheServesRepeatedPhrases(t *testing.T) {
+	cache, next := newTestCache(t, 0)
+	ctx := context.Background()
+
+	first, _ := cache.Translate(ctx, services.TranslationRequest{Text: "hi, how are you?", Source: "en", Target: "pt"})
+	second, err := cache.Translate(ctx, services.TranslationRequest{Text: "  hi,  how are you? ", Source: "en", Target: "pt"})
+	if err != nil || next.calls != 1 {
+		t.Fatalf("provider called %d times, err %v", next.calls, err)
+	}
+	if !second.Cached || second.Text != first.Text {
+		t.Errorf("cached = %+v, first = %+v", second, first)
+	}
+
+	// Outro par de idiomas é outra entrada
+	cache.Translate(ctx, services.TranslationRequest{Text: "hi, how are you?", Source: "en", Target: "es"})
+	if next.calls != 2 {
+		t.Errorf("different target served from cache")
+	}
+
+	stats, _ := cache.Stats(ctx)
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestTranslationCacheHonorsOptOutAndSizeCap(t *testing.T) {
+	cache, next := newTestCache(t, 3)
+	ctx := context.Background()
+
+	secret := services.TranslationRequest{Text: "my address is 12 Main St", Target: "pt", NoCache: true}
+	cache.Translate(ctx, secret)
+	cache.Translate(ctx, secret)
+	if next.calls != 2 {
+		t.Errorf("sensitive message was cached")
+	}
+
+	for i := 0; i < 5; i++ {
+		cache.Translate(ctx, services.TranslationRequest{Text: fmt.Sprintf("phrase %d", i), Target: "pt"})
+	}
+	if stats, _ := cache.Stats(ctx); stats.Entries != 3 {
+		t.Errorf("entries = %d, want cap of 3", stats.Entries)
+	}
+}
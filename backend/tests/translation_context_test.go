@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestConversationWindowStaysWithinBudget(t *testing.T) {
+	w := services.NewConversationWindow(20)
+	for i := 0; i < 10; i++ {
+		// ~5 tokens cada
+		w.Add("a", fmt.Sprintf("message number %02d", i))
+	}
+	if w.Tokens() > 20 {
+		t.Errorf("tokens = %d, budget 20", w.Tokens())
+	}
+
+	msgs := w.Messages()
+	if len(msgs) == 0 || msgs[len(msgs)-1].Text != "message number 09" {
+		t.Fatalf("newest message dropped: %+v", msgs)
+	}
+	if msgs[0].Text == "message number 00" {
+		t.Error("oldest message kept past the budget")
+	}
+
+	// Uma mensagem maior que o orçamento inteiro não fica na janela
+	w.Add("b", strings.Repeat("long ", 40))
+	if w.Tokens() > 20 || len(w.Messages()) != 0 {
+		t.Errorf("oversized message kept: %d tokens", w.Tokens())
+	}
+}
+
+// recordingTranslator repassa cada pedido ao teste antes de traduzir
+type recordingTranslator struct {
+	services.FakeTranslator
+	requests chan services.TranslationRequest
+}
+
+func (r *recordingTranslator) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	r.requests <- req
+	return r.FakeTranslator.Translate(ctx, req)
+}
+
+// newContextPair pareia alice e bob num handler que guarda contexto e grava as traduções
+func newContextPair(t *testing.T) (alice, bob *wsClient, translator *recordingTranslator) {
+	t.Helper()
+	h, _ := newTestWSHandler(t)
+	translator = &recordingTranslator{requests: make(chan services.TranslationRequest, 8)}
+	h.Translator = translator
+	h.ContextTokens = 200
+	srv := serveWS(t, h)
+	alice, bob = dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	pairClients(t, alice, bob)
+	return alice, bob, translator
+}
+
+// chat envia a mensagem e devolve o pedido que chegou ao provedor
+func chat(t *testing.T, from *wsClient, translator *recordingTranslator, payload map[string]interface{}) services.TranslationRequest {
+	t.Helper()
+	from.send("chat_message", payload)
+	select {
+	case req := <-translator.requests:
+		return req
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no translation for %v", payload)
+	}
+	return services.TranslationRequest{}
+}
+
+func TestTranslationContextReachesProvider(t *testing.T) {
+	alice, bob, translator := newContextPair(t)
+
+	if req := chat(t, alice, translator, map[string]interface{}{"text": "oi, tudo bem?"}); len(req.Context) != 0 {
+		t.Errorf("first message carried context: %+v", req.Context)
+	}
+	chat(t, bob, translator, map[string]interface{}{"text": "my password is hunter2", "sensitive": true})
+
+	req := chat(t, bob, translator, map[string]interface{}{"text": "all good, and you?"})
+	want := []services.ContextMessage{{Speaker: "NexusPeer_alic", Text: "oi, tudo bem?"}}
+	if len(req.Context) != len(want) || req.Context[0] != want[0] {
+		t.Errorf("context = %+v, want %+v (sensitive message excluded)", req.Context, want)
+	}
+}
+
+func TestTranslationContextDroppedWhenRoomCloses(t *testing.T) {
+	alice, bob, translator := newContextPair(t)
+	chat(t, alice, translator, map[string]interface{}{"text": "oi, tudo bem?"})
+
+	alice.send("next", nil)
+	alice.expect("matched")
+	bob.expect("matched")
+
+	if req := chat(t, bob, translator, map[string]interface{}{"text": "hello again"}); len(req.Context) != 0 {
+		t.Errorf("new room inherited context: %+v", req.Context)
+	}
+}
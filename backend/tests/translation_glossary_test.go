@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func TestGlossaryPreservesTermsWithFakeProvider(t *testing.T) {
+	// Sem glossário, o fake "traduz" nomes próprios e gírias
+	fake := &services.FakeTranslator{Words: map[string]string{
+		"Nexus":   "Nexo",
+		"Rio":     "Rivers",
+		"hello":   "olá",
+		"saudade": "longing",
+	}}
+	g := &services.GlossaryTranslator{Next: fake}
+
+	req := services.TranslationRequest{
+		Text:   "hello from Rio, I love nexus and saudade",
+		Source: "en",
+		Target: "pt",
+		Glossary: []services.GlossaryTerm{
+			{Term: "Nexus"},
+			{Term: "Rio"},
+			{Term: "saudade", Translation: "saudade", Source: "en", Target: "pt"},
+			// Par de idiomas diferente: não se aplica
+			{Term: "hello", Translation: "oi", Source: "en", Target: "es"},
+		},
+	}
+	got, err := g.Translate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	want := "[pt] olá from Rio, I love nexus and saudade"
+	if got.Text != want {
+		t.Errorf("text = %q, want %q", got.Text, want)
+	}
+
+	var streamed []string
+	result, err := services.TranslateStream(context.Background(), g, req, func(d string) { streamed = append(streamed, d) })
+	if err != nil || result.Text != want || strings.Join(streamed, "") != want {
+		t.Errorf("stream = %q / %+v, %v", streamed, result, err)
+	}
+	for _, d := range streamed {
+		if strings.Contains(d, "{{") {
+			t.Errorf("placeholder leaked into delta %q", d)
+		}
+	}
+}
+
+func TestGlossaryForcesMappingsAndMatchesWholeWords(t *testing.T) {
+	g := &services.GlossaryTranslator{Next: &services.FakeTranslator{}}
+	got, _ := g.Translate(context.Background(), services.TranslationRequest{
+		Text:   "the vox app, not voxel",
+		Source: "en",
+		Target: "pt-BR",
+		Glossary: []services.GlossaryTerm{
+			{Term: "vox", Translation: "VoxBridge", Source: "en", Target: "pt"},
+		},
+	})
+	if got.Text != "[pt-BR] the VoxBridge app, not voxel" {
+		t.Errorf("text = %q", got.Text)
+	}
+}
+
+func TestGlossaryKeepsPlaceholdersTypedByTheUser(t *testing.T) {
+	g := &services.GlossaryTranslator{Next: &services.FakeTranslator{}}
+	req := services.TranslationRequest{
+		Text:     "template {{0}} and {{ 1 }} from Nexus",
+		Source:   "en",
+		Target:   "pt",
+		Glossary: []services.GlossaryTerm{{Term: "Nexus"}},
+	}
+	want := "[pt] template {{0}} and {{ 1 }} from Nexus"
+	got, err := g.Translate(context.Background(), req)
+	if err != nil || got.Text != want {
+		t.Errorf("text = %+v, %v; want %q", got, err, want)
+	}
+
+	var streamed []string
+	services.TranslateStream(context.Background(), g, req, func(d string) { streamed = append(streamed, d) })
+	if strings.Join(streamed, "") != want {
+		t.Errorf("stream = %q", streamed)
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type flakyTranslator struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyTranslator) Name() string { return "flaky" }
+
+func (f *flakyTranslator) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &services.Translation{Text: "ok", Provider: f.Name()}, nil
+}
+
+func TestResilientTranslatorRetriesTransientErrors(t *testing.T) {
+	unavailable := &services.ProviderError{Provider: "flaky", StatusCode: http.StatusServiceUnavailable}
+	next := &flakyTranslator{errs: []error{unavailable, unavailable}}
+	r := &services.ResilientTranslator{Next: next, Retries: 2, Backoff: time.Millisecond, Breaker: &services.CircuitBreaker{}}
+
+	if _, err := r.Translate(context.Background(), services.TranslationRequest{Text: "oi"}); err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if next.calls != 3 {
+		t.Errorf("calls = %d, want 3", next.calls)
+	}
+
+	badRequest := &services.ProviderError{Provider: "flaky", StatusCode: http.StatusBadRequest}
+	next = &flakyTranslator{errs: []error{badRequest}}
+	r.Next = next
+	if _, err := r.Translate(context.Background(), services.TranslationRequest{Text: "oi"}); err == nil || next.calls != 1 {
+		t.Errorf("non-retryable error retried: calls = %d, err = %v", next.calls, err)
+	}
+}
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	down := errors.New("boom")
+	next := &flakyTranslator{errs: []error{down, down, down}}
+	breaker := &services.CircuitBreaker{Threshold: 2, Cooldown: 20 * time.Millisecond}
+	r := &services.ResilientTranslator{Next: next, Retries: 0, Breaker: breaker}
+	ctx := context.Background()
+
+	r.Translate(ctx, services.TranslationRequest{Text: "a"})
+	r.Translate(ctx, services.TranslationRequest{Text: "b"})
+	if _, err := r.Translate(ctx, services.TranslationRequest{Text: "c"}); !errors.Is(err, services.ErrCircuitOpen) {
+		t.Fatalf("breaker should be open, got %v", err)
+	}
+	if next.calls != 2 || breaker.State().State != services.BreakerOpen {
+		t.Fatalf("calls = %d, state = %+v", next.calls, breaker.State())
+	}
+
+	// Depois do cooldown uma chamada de teste passa; falhando, o breaker reabre
+	time.Sleep(25 * time.Millisecond)
+	r.Translate(ctx, services.TranslationRequest{Text: "d"})
+	if breaker.State().State != services.BreakerOpen || next.calls != 3 {
+		t.Fatalf("failed probe: calls = %d, state = %+v", next.calls, breaker.State())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, err := r.Translate(ctx, services.TranslationRequest{Text: "e"}); err != nil {
+		t.Fatalf("probe after recovery: %v", err)
+	}
+	if state := services.TranslatorBreakers(r)["flaky"]; state.State != services.BreakerClosed || state.Failures != 0 {
+		t.Errorf("state after recovery = %+v", state)
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+// brokenStream emite um trecho e cai no meio da resposta
+type brokenStream struct {
+	calls int
+}
+
+func (b *brokenStream) Name() string { return "broken" }
+
+func (b *brokenStream) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	return nil, errors.New("unused")
+}
+
+func (b *brokenStream) TranslateStream(ctx context.Context, req services.TranslationRequest, onDelta func(string)) (*services.Translation, error) {
+	b.calls++
+	onDelta("partial")
+	return nil, context.DeadlineExceeded
+}
+
+func TestTranslateStreamDeliversDeltasInOrder(t *testing.T) {
+	fake := &services.FakeTranslator{Words: map[string]string{"hello": "olá"}}
+	var deltas []string
+	result, err := services.TranslateStream(context.Background(), fake, services.TranslationRequest{Text: "hello my friend", Source: "en", Target: "pt"}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("TranslateStream: %v", err)
+	}
+	if len(deltas) < 2 || strings.Join(deltas, "") != result.Text {
+		t.Errorf("deltas %q do not add up to %q", deltas, result.Text)
+	}
+
+	// Sem suporte a streaming, a tradução inteira chega num único delta
+	deltas = nil
+	services.TranslateStream(context.Background(), &flakyTranslator{}, services.TranslationRequest{Text: "x", Target: "pt"}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if len(deltas) != 1 || deltas[0] != "ok" {
+		t.Errorf("non-streaming provider emitted %q", deltas)
+	}
+}
+
+func TestTranslationCacheStreamsHitsAsSingleDelta(t *testing.T) {
+	cache, _ := newTestCache(t, 0)
+	ctx := context.Background()
+	req := services.TranslationRequest{Text: "good morning everyone", Source: "en", Target: "pt"}
+
+	var first []string
+	services.TranslateStream(ctx, cache, req, func(d string) { first = append(first, d) })
+
+	var second []string
+	result, err := services.TranslateStream(ctx, cache, req, func(d string) { second = append(second, d) })
+	if err != nil || !result.Cached {
+		t.Fatalf("second stream not served from cache: %+v, %v", result, err)
+	}
+	if len(second) != 1 || second[0] != strings.Join(first, "") {
+		t.Errorf("cached deltas = %q, streamed = %q", second, first)
+	}
+}
+
+func TestResilientTranslatorDoesNotRetryStartedStreams(t *testing.T) {
+	next := &brokenStream{}
+	r := &services.ResilientTranslator{Next: next, Retries: 2, Breaker: &services.CircuitBreaker{Threshold: 5}}
+
+	var deltas []string
+	_, err := services.TranslateStream(context.Background(), r, services.TranslationRequest{Text: "x", Target: "pt"}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err == nil || next.calls != 1 {
+		t.Fatalf("calls = %d, err = %v (a retry would duplicate %q)", next.calls, err, deltas)
+	}
+}
+
+// expectStream junta os translation_delta da mensagem até o translation_done
+func (c *wsClient) expectStream(messageID string) (string, map[string]interface{}) {
+	c.t.Helper()
+	var deltas strings.Builder
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed waiting for translation_done")
+			}
+			var payload map[string]interface{}
+			json.Unmarshal(msg.Payload, &payload)
+			if payload["message_id"] != messageID {
+				continue
+			}
+			switch msg.Type {
+			case "translation_delta":
+				deltas.WriteString(payload["delta"].(string))
+			case "translation_done":
+				return deltas.String(), payload
+			case "chat_message":
+				c.t.Fatalf("streaming recipient got a second chat_message: %v", payload)
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for translation_done")
+		}
+	}
+}
+
+func TestStreamReachesEachRecipient(t *testing.T) {
+	// u1 e u2 acompanham o streaming, u3 recebe a mensagem final
+	group := newGroupWithQueries(t, &services.FakeTranslator{}, []string{"pt", "es", "es", "fr"}, []string{"", "stream=1", "stream=1"})
+	group[0].send("chat_message", map[string]string{"text": "bom dia a todos"})
+
+	for _, c := range group[1:3] {
+		pending := c.expect("chat_message")
+		if pending["translation_status"] != "pending" || pending["streaming"] != true {
+			t.Fatalf("pending = %v", pending)
+		}
+		deltas, done := c.expectStream(pending["message_id"].(string))
+		if deltas != "[es] bom dia a todos" || done["translated_text"] != deltas || done["translation_status"] != "ok" || done["target_language"] != "es" {
+			t.Errorf("deltas %q, done %v", deltas, done)
+		}
+	}
+
+	pending := group[3].expect("chat_message")
+	if _, ok := pending["streaming"]; ok {
+		t.Errorf("non-streaming recipient marked as streaming: %v", pending)
+	}
+	if final := group[3].expectFinal(); final["translated_text"] != "[fr] bom dia a todos" || final["message_id"] != pending["message_id"] {
+		t.Errorf("final = %v", final)
+	}
+	group[3].expectNone("translation_delta", 100*time.Millisecond)
+	group[0].expectNone("translation_done", 50*time.Millisecond)
+}
+
+func TestStreamFailingMidwayFinishesWithOriginal(t *testing.T) {
+	group := newGroupWithQueries(t, &brokenStream{}, []string{"pt", "es", "fr"}, []string{"", "stream=1"})
+	group[0].send("chat_message", map[string]string{"text": "bom dia"})
+
+	// O trecho que já saiu fica, e o done traz o original como definitivo
+	pending := group[1].expect("chat_message")
+	deltas, done := group[1].expectStream(pending["message_id"].(string))
+	if deltas != "partial" || done["translated_text"] != "bom dia" || done["translation_status"] != "failed" {
+		t.Errorf("deltas %q, done %v", deltas, done)
+	}
+
+	if final := group[2].expectFinal(); final["translated_text"] != "bom dia" || final["translation_status"] != "failed" {
+		t.Errorf("final = %v", final)
+	}
+}
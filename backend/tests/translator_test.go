@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type failingTranslator struct{ calls int }
+
+func (f *failingTranslator) Name() string { return "broken" }
+
+func (f *failingTranslator) Translate(ctx context.Context, req services.TranslationRequest) (*services.Translation, error) {
+	f.calls++
+	return nil, errors.New("provider down")
+}
+
+func TestFakeTranslatorIsDeterministic(t *testing.T) {
+	fake := &services.FakeTranslator{Words: map[string]string{"hello": "olá"}}
+	req := services.TranslationRequest{Text: "hello world", Source: "en", Target: "pt"}
+
+	first, _ := fake.Translate(context.Background(), req)
+	second, _ := fake.Translate(context.Background(), req)
+	if first.Text != "[pt] olá world" || *first != *second {
+		t.Errorf("fake translation = %+v, %+v", first, second)
+	}
+	if first.SourceLanguage != "en" || first.Provider != "fake" {
+		t.Errorf("metadata = %+v", first)
+	}
+}
+
+func TestChainTranslatorFallsBackInOrder(t *testing.T) {
+	broken := &failingTranslator{}
+	chain := &services.ChainTranslator{Translators: []services.Translator{broken, &services.FakeTranslator{}}}
+
+	res, err := chain.Translate(context.Background(), services.TranslationRequest{Text: "oi", Target: "en"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if broken.calls != 1 || res.Provider != "fake" {
+		t.Errorf("provider = %s after %d failed calls", res.Provider, broken.calls)
+	}
+	if chain.Name() != "chain(broken,fake)" {
+		t.Errorf("Name() = %s", chain.Name())
+	}
+
+	all := &services.ChainTranslator{Translators: []services.Translator{broken}}
+	if _, err := all.Translate(context.Background(), services.TranslationRequest{Text: "oi", Target: "en"}); err == nil {
+		t.Error("chain without working providers should fail")
+	}
+}
+
+func TestHTTPTranslatorSpeaksLibreTranslate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/translate" || body["source"] != "auto" || body["target"] != "pt" || body["q"] != "good morning" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"translatedText":   "bom dia",
+			"detectedLanguage": map[string]interface{}{"language": "en", "confidence": 92.0},
+		})
+	}))
+	defer srv.Close()
+
+	translator := services.NewHTTPTranslator(srv.URL+"/", "")
+	res, err := translator.Translate(context.Background(), services.TranslationRequest{Text: "good morning", Source: "es", Target: "pt-BR"})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if res.Text != "bom dia" || res.SourceLanguage != "en" || res.Provider != "libretranslate" {
+		t.Errorf("translation = %+v", res)
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+func writeTURNConfig(t *testing.T, path, config string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	os.Chtimes(path, modTime, modTime)
+}
+
+func TestTURNCredentialsAreTimeLimitedHMAC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "turn.json")
+	writeTURNConfig(t, path, `{
+		"secret": "coturn-secret",
+		"ttl_seconds": 600,
+		"default_region": "sa",
+		"regions": {"sa": {"host": "turn-sa.example.com"}, "eu": {"turn": ["turn:turn-eu.example.com:3478"]}}
+	}`, time.Now())
+	s := &services.TURNService{ConfigPath: path}
+
+	before := time.Now()
+	resp, err := s.ICEServers("user-1", "unknown")
+	if err != nil {
+		t.Fatalf("ICEServers: %v", err)
+	}
+	if resp.Region != "sa" || resp.TTL != 600 {
+		t.Errorf("region %q ttl %d, want default region sa with ttl 600", resp.Region, resp.TTL)
+	}
+	if len(resp.ICEServers) != 2 || resp.ICEServers[0].URLs[0] != "stun:turn-sa.example.com:3478" {
+		t.Fatalf("ice servers = %+v", resp.ICEServers)
+	}
+
+	turn := resp.ICEServers[1]
+	if len(turn.URLs) != 3 {
+		t.Errorf("host expanded to %v", turn.URLs)
+	}
+	expiry, userID, _ := strings.Cut(turn.Username, ":")
+	exp, _ := strconv.ParseInt(expiry, 10, 64)
+	if userID != "user-1" || exp != resp.ExpiresAt {
+		t.Errorf("username = %q, expires_at %d", turn.Username, resp.ExpiresAt)
+	}
+	if want := before.Add(600 * time.Second).Unix(); exp < want || exp > want+1 {
+		t.Errorf("expiry %d, want about %d", exp, want)
+	}
+
+	mac := hmac.New(sha1.New, []byte("coturn-secret"))
+	mac.Write([]byte(turn.Username))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); turn.Credential != want {
+		t.Errorf("credential = %q, want %q", turn.Credential, want)
+	}
+
+	eu, _ := s.ICEServers("user-1", "eu")
+	if len(eu.ICEServers) != 1 || eu.ICEServers[0].URLs[0] != "turn:turn-eu.example.com:3478" {
+		t.Errorf("explicit urls region = %+v", eu.ICEServers)
+	}
+}
+
+func TestTURNConfigRejectsRegionWithoutServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "turn.json")
+	writeTURNConfig(t, path, `{"secret": "s", "regions": {"sa": {"stun": ["stun:stun.example.com:3478"]}}}`, time.Now())
+
+	s := &services.TURNService{ConfigPath: path}
+	if _, err := s.ICEServers("user-1", "sa"); !errors.Is(err, services.ErrTURNRegionEmpty) {
+		t.Fatalf("err = %v, want %v", err, services.ErrTURNRegionEmpty)
+	}
+
+	// Uma releitura inválida mantém a última configuração boa
+	writeTURNConfig(t, path, `{"secret": "s", "regions": {"sa": {"host": "turn.example.com"}}}`, time.Now().Add(time.Second))
+	if _, err := s.ICEServers("user-1", "sa"); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	writeTURNConfig(t, path, `{"secret": "s", "regions": {"sa": {"host": "turn.example.com"}, "eu": {}}}`, time.Now().Add(2*time.Second))
+	resp, err := s.ICEServers("user-1", "sa")
+	if err != nil || len(resp.ICEServers[len(resp.ICEServers)-1].URLs) == 0 {
+		t.Fatalf("previous config not kept: %+v, %v", resp, err)
+	}
+}
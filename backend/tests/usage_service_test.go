@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/middleware"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+type tierList map[string]string
+
+func (t tierList) Tier(userID string) (string, error) { return t[userID], nil }
+
+func newTestUsage(t *testing.T) *services.UsageService {
+	t.Helper()
+	rdb, _ := newTestRedis(t)
+	return &services.UsageService{
+		Redis:      rdb,
+		Users:      tierList{"paid": "pro"},
+		TierQuotas: map[string]int64{"free": 100, "pro": 1000},
+	}
+}
+
+func TestMeteredTranslatorEnforcesTierQuota(t *testing.T) {
+	usage := newTestUsage(t)
+	next := &countingTranslator{}
+	m := &services.MeteredTranslator{Next: next, Usage: usage}
+	ctx := context.Background()
+
+	usage.Record(ctx, "spammer", 400, 100)
+	usage.Record(ctx, "paid", 400, 100)
+
+	_, err := m.Translate(ctx, services.TranslationRequest{Text: "hi", Target: "pt", UserID: "spammer"})
+	var quota *services.QuotaError
+	if !errors.Is(err, services.ErrQuotaExceeded) || !errors.As(err, &quota) {
+		t.Fatalf("err = %v, want quota error", err)
+	}
+	if quota.Scope != services.QuotaScopeUser || quota.Tier != "free" || quota.Used != 100 {
+		t.Errorf("quota = %+v", quota)
+	}
+	if next.calls != 0 {
+		t.Error("provider called past the quota")
+	}
+
+	// Plano pro tem cota maior; a chamada passa e é contabilizada
+	if _, err := m.Translate(ctx, services.TranslationRequest{Text: "hello there", Target: "pt", UserID: "paid"}); err != nil {
+		t.Fatalf("pro user blocked: %v", err)
+	}
+	report, _ := usage.Report(ctx, "", 10)
+	if report.TopUsers[0].UserID != "paid" || report.TopUsers[0].Requests != 2 || report.TopUsers[0].Tokens <= 100 {
+		t.Errorf("report = %+v", report.TopUsers)
+	}
+	if report.Global.Characters != 811 {
+		t.Errorf("global characters = %d", report.Global.Characters)
+	}
+}
+
+func TestGlobalCeilingStopsEveryone(t *testing.T) {
+	usage := newTestUsage(t)
+	usage.GlobalDailyTokens = 50
+	ctx := context.Background()
+
+	usage.Record(ctx, "a", 10, 60)
+	var quota *services.QuotaError
+	if err := usage.Check(ctx, "someone-else"); !errors.As(err, &quota) || quota.Scope != services.QuotaScopeGlobal {
+		t.Fatalf("err = %v", err)
+	}
+	if quota.ResetsAt.IsZero() {
+		t.Error("resets_at missing")
+	}
+}
+
+// countingTiers conta as consultas ao plano (o SELECT no Postgres em produção)
+type countingTiers struct {
+	tierList
+	calls int
+}
+
+func (c *countingTiers) Tier(userID string) (string, error) {
+	c.calls++
+	return c.tierList.Tier(userID)
+}
+
+func TestTierIsCachedInRedis(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	tiers := &countingTiers{tierList: tierList{"paid": "pro"}}
+	usage := &services.UsageService{Redis: rdb, Users: tiers, TierQuotas: map[string]int64{"free": 100, "pro": 1000}}
+	ctx := context.Background()
+	usage.Record(ctx, "paid", 400, 500)
+
+	for i := 0; i < 3; i++ {
+		if err := usage.Check(ctx, "paid"); err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+	}
+	if tiers.calls != 1 {
+		t.Errorf("tier source called %d times, want 1", tiers.calls)
+	}
+
+	// Passado o TTL, o plano é relido e um rebaixamento passa a valer
+	tiers.tierList["paid"] = "free"
+	mr.FastForward(11 * time.Minute)
+	if err := usage.Check(ctx, "paid"); !errors.Is(err, services.ErrQuotaExceeded) {
+		t.Errorf("downgraded user: err = %v", err)
+	}
+	if tiers.calls != 2 {
+		t.Errorf("tier source called %d times after expiry, want 2", tiers.calls)
+	}
+}
+
+func TestQuotaExceededReachesOnlySender(t *testing.T) {
+	usage := newTestUsage(t)
+	usage.Record(context.Background(), "u0-pt", 400, 100)
+	group := newTranslatedGroup(t, &services.MeteredTranslator{Next: &services.FakeTranslator{}, Usage: usage}, "pt", "es", "fr")
+
+	group[0].send("chat_message", map[string]string{"text": "bom dia"})
+	var messageID interface{}
+	for _, c := range group[1:] {
+		final := c.expectFinal()
+		if final["translation_status"] != "quota_exceeded" || final["translated_text"] != "bom dia" {
+			t.Errorf("final = %v", final)
+		}
+		messageID = final["message_id"]
+		c.expectNone("quota_exceeded", 50*time.Millisecond)
+	}
+
+	// Um aviso só, mesmo com dois idiomas estourando a cota
+	got := group[0].expect("quota_exceeded")
+	if got["message_id"] != messageID || got["scope"] != services.QuotaScopeUser || got["tier"] != "free" ||
+		got["limit"] != float64(100) || got["used"] != float64(100) || got["resets_at"] == nil {
+		t.Errorf("quota_exceeded = %v", got)
+	}
+	group[0].expectNone("quota_exceeded", 100*time.Millisecond)
+}
+
+func TestAdminUsageReport(t *testing.T) {
+	usage := newTestUsage(t)
+	usage.Record(context.Background(), "paid", 40, 300)
+	handler := &controllers.UsageHandler{UsageService: usage}
+
+	gin.SetMode(gin.TestMode)
+	get := func(adminToken, authorization, path string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/v1/admin/usage", middleware.AdminRequired(adminToken), handler.HandleUsageReport)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		name, adminToken, authorization, path string
+		code                                  int
+		errCode                               string
+	}{
+		{"admin disabled", "", "Bearer anything", "/v1/admin/usage", http.StatusServiceUnavailable, "admin_disabled"},
+		{"missing token", "s3cret", "", "/v1/admin/usage", http.StatusUnauthorized, "invalid_admin_token"},
+		{"wrong token", "s3cret", "Bearer s3cre", "/v1/admin/usage", http.StatusUnauthorized, "invalid_admin_token"},
+		{"invalid date", "s3cret", "Bearer s3cret", "/v1/admin/usage?date=17/10/2026", http.StatusBadRequest, "invalid_date"},
+	} {
+		w := get(tc.adminToken, tc.authorization, tc.path)
+		var body map[string]string
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tc.code || body["error"] != tc.errCode {
+			t.Errorf("%s: %d %s", tc.name, w.Code, w.Body)
+		}
+	}
+
+	w := get("s3cret", "Bearer s3cret", "/v1/admin/usage?limit=5")
+	var report services.UsageReport
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &report) != nil {
+		t.Fatalf("report: %d %s", w.Code, w.Body)
+	}
+	if report.Date != time.Now().UTC().Format("2006-01-02") || len(report.TopUsers) != 1 || report.TopUsers[0].UserID != "paid" || report.TierQuotas["pro"] != 1000 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Um dia sem uso responde vazio, não erro
+	w = get("s3cret", "Bearer s3cret", "/v1/admin/usage?date=2020-01-01")
+	if w.Code != http.StatusOK {
+		t.Errorf("past day: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectKeepsRoomWhenOldSocketCloses(t *testing.T) {
+	h, ms := newTestWSHandler(t)
+	srv := serveWS(t, h)
+	alice, bob := dialWS(t, srv, "alice", ""), dialWS(t, srv, "bob", "")
+	roomID := pairClients(t, alice, bob)
+
+	// A aba nova entra antes de a antiga cair
+	again := dialWS(t, srv, "alice", "")
+	alice.conn.Close()
+
+	bob.expectNone("partner_left", 200*time.Millisecond)
+	if current, _ := ms.CurrentRoom("alice"); current != roomID {
+		t.Fatalf("room after reconnect = %q, want %q", current, roomID)
+	}
+
+	again.send("webrtc_offer", map[string]interface{}{"sdp": map[string]string{"type": "offer", "sdp": testSDP}})
+	bob.expect("webrtc_offer")
+
+	// A queda da conexão atual ainda encerra a sala
+	again.conn.Close()
+	if got := bob.expect("partner_left"); got["reason"] != "disconnected" {
+		t.Errorf("partner_left = %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/vox-bridge/nexus-core/src/controllers"
+	"github.com/vox-bridge/nexus-core/src/services"
+)
+
+const testJWTSecret = "test-jwt-secret"
+
+// newTestWSHandler monta um WSHandler com Redis em memória e sem provedores externos
+func newTestWSHandler(t *testing.T) (*controllers.WSHandler, *services.MatchService) {
+	t.Helper()
+	ms, _ := newTestMatchService(t)
+	h := controllers.NewWSHandler(nil, ms, &services.AuthService{JWTSecret: []byte(testJWTSecret)})
+	return h, ms
+}
+
+func serveWS(t *testing.T, h *controllers.WSHandler) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/ws", h.HandleWS)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type wsClient struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	messages chan controllers.WSMessage
+}
+
+// dialWS conecta userID com um JWT válido; query é repassada (ex.: "stream=1")
+func dialWS(t *testing.T, srv *httptest.Server, userID, query string) *wsClient {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, services.Claims{UserID: userID}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/ws?token=" + token
+	if query != "" {
+		url += "&" + query
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", userID, err)
+	}
+
+	c := &wsClient{t: t, conn: conn, messages: make(chan controllers.WSMessage, 64)}
+	go func() {
+		defer close(c.messages)
+		for {
+			var msg controllers.WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { conn.Close() })
+	c.expect("connected")
+	return c
+}
+
+func (c *wsClient) send(msgType string, payload interface{}) {
+	c.t.Helper()
+	c.sendAs("", msgType, payload)
+}
+
+// sendAs envia em nome de uma sessão (usado pela conexão do bot)
+func (c *wsClient) sendAs(session, msgType string, payload interface{}) {
+	c.t.Helper()
+	data, _ := json.Marshal(payload)
+	if err := c.conn.WriteJSON(controllers.WSMessage{Type: msgType, Payload: data, Session: session}); err != nil {
+		c.t.Fatalf("send %s: %v", msgType, err)
+	}
+}
+
+// expect descarta outras mensagens até chegar uma do tipo pedido
+func (c *wsClient) expect(msgType string) map[string]interface{} {
+	c.t.Helper()
+	var payload map[string]interface{}
+	json.Unmarshal(c.expectMessage(msgType).Payload, &payload)
+	return payload
+}
+
+func (c *wsClient) expectMessage(msgType string) controllers.WSMessage {
+	c.t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("connection closed waiting for %s", msgType)
+			}
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for %s", msgType)
+		}
+	}
+}
+
+// expectNone falha se chegar uma mensagem do tipo dado dentro de d
+func (c *wsClient) expectNone(msgType string, d time.Duration) {
+	c.t.Helper()
+	timeout := time.After(d)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				return
+			}
+			if msg.Type == msgType {
+				c.t.Fatalf("unexpected %s: %s", msgType, msg.Payload)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// pairClients coloca os dois na fila (pt <-> en) e espera o matched de ambos
+func pairClients(t *testing.T, a, b *wsClient) string {
+	t.Helper()
+	a.send("join_queue", services.MatchRequest{NativeLanguage: "pt", TargetLanguage: "en"})
+	a.expect("queue_joined")
+	b.send("join_queue", services.MatchRequest{NativeLanguage: "en", TargetLanguage: "pt"})
+	roomID := a.expect("matched")["room_id"].(string)
+	if got := b.expect("matched")["room_id"]; got != roomID {
+		t.Fatalf("partners got different rooms: %v and %v", roomID, got)
+	}
+	return roomID
+}
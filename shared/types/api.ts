@@ -17,6 +17,8 @@ export interface MatchJoinRequest {
 
 export interface MatchStatusResponse {
   status: 'searching' | 'matched';
+  position?: number;
+  eta_seconds?: number;
   room_id?: string;
   partner_id?: string;
   partner_language?: Language;